@@ -4,6 +4,7 @@ go 1.23
 
 require (
 	github.com/goccy/go-yaml v1.12.0
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/stretchr/testify v1.9.0
 	go.uber.org/mock v0.4.0
 )
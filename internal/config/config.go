@@ -11,10 +11,11 @@ import (
 )
 
 type Config struct {
-	Period  *models.Seconds
-	Fans    []Fan
-	Sensors []Sensor
-	Profile *Profile
+	Period       *models.Seconds
+	Fans         []Fan
+	Sensors      []Sensor
+	Profile      *Profile
+	StallCommand []string `yaml:"stallCommand"`
 }
 
 type Fan struct {
@@ -32,9 +33,32 @@ type Fan struct {
 	Levels    []Level
 	Profiles  []ProfileLevels
 
+	StallTimeout   *models.Seconds `yaml:"stallTimeout"`
+	EmergencyLevel string          `yaml:"emergencyLevel"`
+	FailsafeLevel  string          `yaml:"failsafeLevel"`
+	Kickstart      *Kickstart
+
 	Path         string
 	RawLevel     bool   `yaml:"rawLevel"`
 	SuspendLevel string `yaml:"suspendLevel"`
+	Sensor       string
+	Index        *int
+	Watchdog     *models.Seconds
+	Device       string
+	MaxLevel     int `yaml:"maxLevel"`
+
+	Command        []string
+	InitCommand    []string `yaml:"initCommand"`
+	RestoreCommand []string `yaml:"restoreCommand"`
+	Timeout        *models.Seconds
+
+	Values   map[string]string
+	ReadBack bool `yaml:"readBack"`
+}
+
+type Kickstart struct {
+	Level    string
+	Duration *models.Seconds
 }
 
 type ProfileLevels struct {
@@ -55,20 +79,51 @@ type Level struct {
 }
 
 type Sensor struct {
-	Name   string
-	Type   string
-	Factor *float64
-	Add    *float64
+	Name      string
+	Type      string
+	Factor    *float64
+	Add       *float64
+	OnError   string   `yaml:"onError"`
+	KeepTicks *int     `yaml:"keepTicks"`
+	ValidMin  *float64 `yaml:"validMin"`
+	ValidMax  *float64 `yaml:"validMax"`
+	MaxStep   *float64 `yaml:"maxStep"`
+	Filter    *Filter
+
+	Sensor      string
+	SensorRegex string `yaml:"sensorRegex"`
+	Device      string
+	Label       string
+	LabelRegex  string `yaml:"labelRegex"`
+	Input       string
+	Class       string
+	Select      string
+	Path        string
+	Mode        string
+
+	Command  []string
+	Regex    string
+	JSONPath string `yaml:"jsonPath"`
+	Interval *models.Seconds
+	Timeout  *models.Seconds
+
+	Expr string
+
+	Serial  string
+	Model   string
+	Standby string
+}
 
-	Sensor string
-	Label  string
-	Select string
-	Path   string
+type Filter struct {
+	Type   string
+	Alpha  *float64
+	Window *int
 }
 
 type Profile struct {
-	Type string
-	Path string
+	Type    string
+	Path    string
+	Address string
 }
 
 func Load(path string) (Config, error) {
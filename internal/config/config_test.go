@@ -32,6 +32,7 @@ func TestConfigLoadFull(t *testing.T) {
       delayUp: 4
       delayDown: 5
       repeat: 30
+      watchdog: 40
       select: average
       path: /some/path
       sensors:
@@ -89,6 +90,7 @@ func TestConfigLoadFull(t *testing.T) {
 				DelayUp:      models.SecondsPtr(4.0),
 				DelayDown:    models.SecondsPtr(5.0),
 				Repeat:       models.SecondsPtr(30),
+				Watchdog:     models.SecondsPtr(40),
 				Select:       models.SelectFuncAverage,
 				Path:         "/some/path",
 				Profiles: []ProfileLevels{
@@ -156,6 +158,7 @@ func TestLoadConf_ReplacesNotCriticalMistakes(t *testing.T) {
       select: fake
       sensors: [s2, s2]
       repeat: 0.9
+      watchdog: 121
       level: ddd
       delay: 101
       delayUp: 101
@@ -248,7 +251,7 @@ func TestLoadConfig_Validation(t *testing.T) {
 		},
 		{
 			name: "wrong fan type",
-			err:  "fans[0].type: must be one of [thinkpad]",
+			err:  "fans[0].type: must be one of [thinkpad, hwmon, cooling, dell, command, file]",
 			yml: `
         sensors:
         - type: hwmon
@@ -381,7 +384,7 @@ func TestLoadConfig_Validation(t *testing.T) {
 		},
 		{
 			name: "wrong sensor type",
-			err:  "sensors[0].type: must be one of [hwmon]",
+			err:  "sensors[0].type: must be one of [hwmon, thermal, file, command, cpuload, rapl, power_supply, virtual, disk]",
 			yml: `
         fans:
         - type: thinkpad
@@ -394,7 +397,7 @@ func TestLoadConfig_Validation(t *testing.T) {
 		},
 		{
 			name: "wrong profile type",
-			err:  "profile.type: must be one of [platform]",
+			err:  "profile.type: must be one of [platform, power_supply, ppd]",
 			yml: `
         fans:
         - type: thinkpad
@@ -405,6 +408,141 @@ func TestLoadConfig_Validation(t *testing.T) {
         - type: hwmon
         profile:
           type: fake
+      `,
+		},
+		{
+			name: "file sensor without path",
+			err:  "sensors[0].path: must be set",
+			yml: `
+        fans:
+        - type: thinkpad
+          levels:
+          - level: 1
+            max: 2
+        sensors:
+        - type: file
+      `,
+		},
+		{
+			name: "sensor valid range",
+			err:  "sensors[0]: validMin must be less than validMax",
+			yml: `
+        fans:
+        - type: thinkpad
+          levels:
+          - level: 1
+            max: 2
+        sensors:
+        - type: hwmon
+          validMin: 150
+          validMax: 0
+      `,
+		},
+		{
+			name: "sensor valid range of unsupported type",
+			err:  "sensors[0]: validMin, validMax and maxStep are supported only by [hwmon, thermal, file, power_supply, disk]",
+			yml: `
+        fans:
+        - type: thinkpad
+          levels:
+          - level: 1
+            max: 2
+        sensors:
+        - type: command
+          command: [echo, "1"]
+          maxStep: 10
+      `,
+		},
+		{
+			name: "wrong sensor filter type",
+			err:  "sensors[0].filter.type: must be one of [ema, mean, median]",
+			yml: `
+        fans:
+        - type: thinkpad
+          levels:
+          - level: 1
+            max: 2
+        sensors:
+        - type: hwmon
+          filter:
+            type: fake
+      `,
+		},
+		{
+			name: "virtual sensor without expr",
+			err:  "sensors[0].expr: must be set",
+			yml: `
+        fans:
+        - type: thinkpad
+          levels:
+          - level: 1
+            max: 2
+        sensors:
+        - type: virtual
+      `,
+		},
+		{
+			name: "virtual sensor with unknown sensor",
+			err:  "sensors[1].expr: unknown sensor gpu",
+			yml: `
+        fans:
+        - type: thinkpad
+          levels:
+          - level: 1
+            max: 2
+        sensors:
+        - type: hwmon
+          name: cpu
+        - type: virtual
+          expr: max(cpu, gpu - 10)
+      `,
+		},
+		{
+			name: "virtual sensors cycle",
+			err:  "sensors: dependency cycle [a b a]",
+			yml: `
+        fans:
+        - type: thinkpad
+          levels:
+          - level: 1
+            max: 2
+        sensors:
+        - type: virtual
+          name: a
+          expr: b + 1
+        - type: virtual
+          name: b
+          expr: d(a)/dt
+      `,
+		},
+		{
+			name: "wrong hwmon input",
+			err:  "sensors[0].input: must be hwmon input name like temp1",
+			yml: `
+        fans:
+        - type: thinkpad
+          levels:
+          - level: 1
+            max: 2
+        sensors:
+        - type: hwmon
+          input: temp1_input
+      `,
+		},
+		{
+			name: "file fan without path",
+			err:  "fans[0].path: must be set",
+			yml: `
+        fans:
+        - type: file
+          level: normal
+          values:
+            normal: 0
+          levels:
+          - level: normal
+            max: 2
+        sensors:
+        - type: hwmon
       `,
 		},
 	}
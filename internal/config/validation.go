@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/IvanSafonov/fanctl/internal/expr"
 	"github.com/IvanSafonov/fanctl/internal/models"
 )
 
@@ -58,9 +61,131 @@ func validateSensors(config *Config) error {
 			return fmt.Errorf("%s.type: must be one of [%s]", sensorPrefix, strings.Join(models.SensorTypes, ", "))
 		}
 
+		if sensor.Type == models.SensorTypeFile && sensor.Path == "" {
+			return fmt.Errorf("%s.path: must be set", sensorPrefix)
+		}
+
+		if sensor.Type == models.SensorTypeCommand && len(sensor.Command) == 0 {
+			return fmt.Errorf("%s.command: must be set", sensorPrefix)
+		}
+
+		if sensor.Type == models.SensorTypeVirtual {
+			if sensor.Expr == "" {
+				return fmt.Errorf("%s.expr: must be set", sensorPrefix)
+			}
+
+			if _, err := expr.Parse(sensor.Expr); err != nil {
+				return fmt.Errorf("%s.expr: %w", sensorPrefix, err)
+			}
+		}
+
+		if sensor.Type == models.SensorTypeCPULoad && sensor.Mode != "" && !slices.Contains(models.CPULoadModes, sensor.Mode) {
+			slog.Warn(fmt.Sprintf("%s.mode: must be one of [%s]", sensorPrefix, strings.Join(models.CPULoadModes, ", ")))
+			sensor.Mode = ""
+		}
+
+		if sensor.Type == models.SensorTypeHwmon && sensor.Class != "" && !slices.Contains(models.HwmonClasses, sensor.Class) {
+			slog.Warn(fmt.Sprintf("%s.class: must be one of [%s]", sensorPrefix, strings.Join(models.HwmonClasses, ", ")))
+			sensor.Class = ""
+		}
+
+		if sensor.Type == models.SensorTypeDisk && sensor.Standby != "" && !slices.Contains(models.DiskStandbyModes, sensor.Standby) {
+			slog.Warn(fmt.Sprintf("%s.standby: must be one of [%s]", sensorPrefix, strings.Join(models.DiskStandbyModes, ", ")))
+			sensor.Standby = ""
+		}
+
+		if sensor.Type == models.SensorTypePowerSupply && sensor.Mode != "" && !slices.Contains(models.PowerSupplyModes, sensor.Mode) {
+			slog.Warn(fmt.Sprintf("%s.mode: must be one of [%s]", sensorPrefix, strings.Join(models.PowerSupplyModes, ", ")))
+			sensor.Mode = ""
+		}
+
+		if _, err := regexp.Compile(sensor.Regex); err != nil {
+			return fmt.Errorf("%s.regex: %w", sensorPrefix, err)
+		}
+
+		if _, err := regexp.Compile(sensor.SensorRegex); err != nil {
+			return fmt.Errorf("%s.sensorRegex: %w", sensorPrefix, err)
+		}
+
+		if _, err := regexp.Compile(sensor.LabelRegex); err != nil {
+			return fmt.Errorf("%s.labelRegex: %w", sensorPrefix, err)
+		}
+
+		if sensor.Type == models.SensorTypeHwmon && sensor.Input != "" && !models.HwmonInputRegex.MatchString(sensor.Input) {
+			return fmt.Errorf("%s.input: must be hwmon input name like temp1", sensorPrefix)
+		}
+
+		if sensor.Interval != nil && !InRange(0, *sensor.Interval, 3600) {
+			slog.Warn(fmt.Sprintf("%s.interval: must be within [0, 3600]", sensorPrefix))
+			sensor.Interval = nil
+		}
+
+		if sensor.Timeout != nil && !InRange(0.1, *sensor.Timeout, 600) {
+			slog.Warn(fmt.Sprintf("%s.timeout: must be within [0.1, 600]", sensorPrefix))
+			sensor.Timeout = nil
+		}
+
 		if !validateSelect(sensor.Select, sensorPrefix) {
 			sensor.Select = ""
 		}
+
+		if sensor.OnError != "" && !slices.Contains(models.SensorOnErrors, sensor.OnError) {
+			slog.Warn(fmt.Sprintf("%s.onError: must be one of [%s]", sensorPrefix, strings.Join(models.SensorOnErrors, ", ")))
+			sensor.OnError = ""
+		}
+
+		hasGuard := sensor.ValidMin != nil || sensor.ValidMax != nil || sensor.MaxStep != nil
+		if hasGuard && !slices.Contains(models.SensorGuardTypes, sensor.Type) {
+			return fmt.Errorf("%s: validMin, validMax and maxStep are supported only by [%s]", sensorPrefix,
+				strings.Join(models.SensorGuardTypes, ", "))
+		}
+
+		if sensor.ValidMin != nil && sensor.ValidMax != nil && *sensor.ValidMin >= *sensor.ValidMax {
+			return fmt.Errorf("%s: validMin must be less than validMax", sensorPrefix)
+		}
+
+		if sensor.MaxStep != nil && *sensor.MaxStep <= 0 {
+			slog.Warn(fmt.Sprintf("%s.maxStep: must be greater than 0", sensorPrefix))
+			sensor.MaxStep = nil
+		}
+
+		if sensor.Filter != nil {
+			if err := validateFilter(sensor.Filter, sensorPrefix+".filter"); err != nil {
+				return err
+			}
+		}
+
+		if sensor.KeepTicks != nil && !InRange(0, *sensor.KeepTicks, 1000) {
+			slog.Warn(fmt.Sprintf("%s.keepTicks: must be within [0, 1000]", sensorPrefix))
+			sensor.KeepTicks = nil
+		}
+	}
+
+	return validateVirtualSensors(config.Sensors)
+}
+
+// Checks that virtual sensors use existing sensors without dependency cycles
+func validateVirtualSensors(sensors []Sensor) error {
+	deps := make(map[string][]string)
+
+	for sensorIdx, sensor := range sensors {
+		if sensor.Type != models.SensorTypeVirtual {
+			continue
+		}
+
+		e, _ := expr.Parse(sensor.Expr)
+		names := expr.Names(e)
+		for _, name := range names {
+			if !slices.ContainsFunc(sensors, func(s Sensor) bool { return s.Name == name }) {
+				return fmt.Errorf("sensors[%d].expr: unknown sensor %s", sensorIdx, name)
+			}
+		}
+
+		deps[sensor.Name] = names
+	}
+
+	if _, err := expr.Order(deps); err != nil {
+		return fmt.Errorf("sensors: %w", err)
 	}
 
 	return nil
@@ -103,11 +228,69 @@ func validateFans(config *Config) error {
 			return fmt.Errorf("%s.type: must be one of [%s]", fanPrefix, strings.Join(models.FanTypes, ", "))
 		}
 
+		if fan.Type == models.FanTypeHwmon && fan.Sensor == "" {
+			return fmt.Errorf("%s.sensor: must be set", fanPrefix)
+		}
+
+		if fan.Type == models.FanTypeCooling && fan.Device == "" {
+			return fmt.Errorf("%s.device: must be set", fanPrefix)
+		}
+
+		if fan.Type == models.FanTypeCommand {
+			if len(fan.Command) == 0 {
+				return fmt.Errorf("%s.command: must be set", fanPrefix)
+			}
+
+			if fan.Level == "" {
+				return fmt.Errorf("%s.level: must be set", fanPrefix)
+			}
+		}
+
+		if fan.Type == models.FanTypeFile {
+			if fan.Path == "" {
+				return fmt.Errorf("%s.path: must be set", fanPrefix)
+			}
+
+			if fan.Level == "" {
+				return fmt.Errorf("%s.level: must be set", fanPrefix)
+			}
+		}
+
+		if fan.Timeout != nil && !InRange(0.1, *fan.Timeout, 600) {
+			slog.Warn(fmt.Sprintf("%s.timeout: must be within [0.1, 600]", fanPrefix))
+			fan.Timeout = nil
+		}
+
+		if fan.Type == models.FanTypeDell && fan.MaxLevel != 0 && !InRange(2, fan.MaxLevel, 3) {
+			slog.Warn(fmt.Sprintf("%s.maxLevel: must be within [2, 3]", fanPrefix))
+			fan.MaxLevel = 0
+		}
+
+		if fan.Index != nil && *fan.Index < 1 {
+			slog.Warn(fmt.Sprintf("%s.index: must be positive", fanPrefix))
+			fan.Index = nil
+		}
+
+		if fan.Type == models.FanTypeThinkpad && fan.Index != nil && *fan.Index > 2 {
+			slog.Warn(fmt.Sprintf("%s.index: must be within [1, 2]", fanPrefix))
+			fan.Index = nil
+		}
+
 		if fan.Repeat != nil && !InRange(1, *fan.Repeat, 3600) {
 			slog.Warn(fmt.Sprintf("%s.repeat: must be within [1, 3600]", fanPrefix))
 			fan.Repeat = nil
 		}
 
+		if fan.Watchdog != nil && !InRange(1, *fan.Watchdog, 120) {
+			slog.Warn(fmt.Sprintf("%s.watchdog: must be within [1, 120]", fanPrefix))
+			fan.Watchdog = nil
+		}
+
+		if fan.Watchdog != nil && fan.Repeat != nil && *fan.Repeat >= *fan.Watchdog {
+			slog.Warn(fmt.Sprintf("%s.repeat: must be less than watchdog", fanPrefix))
+			fan.Repeat = nil
+		}
+
 		if fan.Level != "" {
 			fan.Level = validateLevel(fan.Level, fanPrefix, fan)
 		}
@@ -116,6 +299,32 @@ func validateFans(config *Config) error {
 			fan.SuspendLevel = validateLevel(fan.SuspendLevel, fanPrefix+".suspend", fan)
 		}
 
+		if fan.FailsafeLevel != "" {
+			fan.FailsafeLevel = validateLevel(fan.FailsafeLevel, fanPrefix+".failsafe", fan)
+		}
+
+		if fan.EmergencyLevel != "" {
+			fan.EmergencyLevel = validateLevel(fan.EmergencyLevel, fanPrefix+".emergency", fan)
+		}
+
+		if fan.Kickstart != nil {
+			if fan.Kickstart.Level == "" {
+				return fmt.Errorf("%s.kickstart.level: must be set", fanPrefix)
+			}
+
+			fan.Kickstart.Level = validateLevel(fan.Kickstart.Level, fanPrefix+".kickstart", fan)
+
+			if fan.Kickstart.Duration != nil && !InRange(0.1, *fan.Kickstart.Duration, 30) {
+				slog.Warn(fmt.Sprintf("%s.kickstart.duration: must be within [0.1, 30]", fanPrefix))
+				fan.Kickstart.Duration = nil
+			}
+		}
+
+		if fan.StallTimeout != nil && !InRange(1, *fan.StallTimeout, 600) {
+			slog.Warn(fmt.Sprintf("%s.stallTimeout: must be within [1, 600]", fanPrefix))
+			fan.StallTimeout = nil
+		}
+
 		validateDelay(&fan.Delay, fanPrefix+".delay")
 		validateDelay(&fan.DelayUp, fanPrefix+".delayUp")
 		validateDelay(&fan.DelayDown, fanPrefix+".delayDown")
@@ -225,6 +434,24 @@ func validateDelay(delay **models.Seconds, paramName string) {
 	}
 }
 
+func validateFilter(filter *Filter, filterPrefix string) error {
+	if !slices.Contains(models.FilterTypes, filter.Type) {
+		return fmt.Errorf("%s.type: must be one of [%s]", filterPrefix, strings.Join(models.FilterTypes, ", "))
+	}
+
+	if filter.Alpha != nil && (*filter.Alpha <= 0 || *filter.Alpha > 1) {
+		slog.Warn(fmt.Sprintf("%s.alpha: must be within (0, 1]", filterPrefix))
+		filter.Alpha = nil
+	}
+
+	if filter.Window != nil && !InRange(1, *filter.Window, 100) {
+		slog.Warn(fmt.Sprintf("%s.window: must be within [1, 100]", filterPrefix))
+		filter.Window = nil
+	}
+
+	return nil
+}
+
 func validateSelect(value, paramPrefix string) bool {
 	if value != "" && !slices.Contains(models.SelectFuncs, value) {
 		slog.Warn(fmt.Sprintf("%s.select: must be one of [%s]", paramPrefix, strings.Join(models.SelectFuncs, ", ")))
@@ -235,21 +462,49 @@ func validateSelect(value, paramPrefix string) bool {
 }
 
 func validateLevel(level, paramPrefix string, fan *Fan) string {
-	if fan.Type != models.FanTypeThinkpad || fan.RawLevel {
-		return level
+	switch fan.Type {
+	case models.FanTypeThinkpad:
+		if fan.RawLevel {
+			return level
+		}
+
+		level = strings.TrimPrefix(strings.TrimSpace(level), "level ")
+		validateLevelSet(level, paramPrefix, thinkpadLevels)
+	case models.FanTypeDell:
+		level = strings.TrimSpace(level)
+		validateLevelSet(level, paramPrefix, dellLevels[:cmp.Or(fan.MaxLevel, 2)+2])
+	case models.FanTypeFile:
+		if len(fan.Values) != 0 {
+			level = strings.TrimSpace(level)
+			validateLevelSet(level, paramPrefix, slices.Sorted(maps.Keys(fan.Values)))
+		}
+	case models.FanTypeHwmon:
+		level = strings.TrimSpace(level)
+		if value, err := strconv.Atoi(level); err != nil || !InRange(0, value, 255) {
+			slog.Warn(fmt.Sprintf("%s.level: should be within [0, 255]", paramPrefix))
+		}
+	case models.FanTypeCooling:
+		level = strings.TrimSpace(level)
+		if value, err := strconv.Atoi(level); level != "max" && (err != nil || value < 0) {
+			slog.Warn(fmt.Sprintf("%s.level: should be max or non-negative integer", paramPrefix))
+		}
 	}
 
-	level = strings.TrimPrefix(strings.TrimSpace(level), "level ")
-	if !slices.Contains(thinkpadLevels, level) {
+	return level
+}
+
+func validateLevelSet(level, paramPrefix string, levels []string) {
+	if !slices.Contains(levels, level) {
 		slog.Warn(fmt.Sprintf("%s.level: should be one of [%s]",
-			paramPrefix, strings.Join(thinkpadLevels, ", ")))
+			paramPrefix, strings.Join(levels, ", ")))
 	}
-	return level
 }
 
 var thinkpadLevels = []string{"0", "1", "2", "3", "4", "5", "6", "7",
 	"auto", "disengaged", "full-speed"}
 
+var dellLevels = []string{"auto", "0", "1", "2", "3"}
+
 func InRange[T cmp.Ordered](min T, value T, max T) bool {
 	return value >= min && value <= max
 }
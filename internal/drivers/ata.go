@@ -0,0 +1,116 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	sgIO          = 0x2285
+	sgDxferNone   = -1
+	sgTimeoutMsec = 5000
+
+	ataPassThrough16  = 0x85
+	ataProtoNonData   = 3 << 1
+	ataCheckCondition = 0x20
+	ataCheckPowerMode = 0xe5
+
+	ataStatusErr = 0x01
+)
+
+// SG_IO request header from linux/scsi/sg.h
+type sgIOHeader struct {
+	interfaceID    int32
+	dxferDirection int32
+	cmdLen         uint8
+	mxSbLen        uint8
+	iovecCount     uint16
+	dxferLen       uint32
+	dxferp         unsafe.Pointer
+	cmdp           unsafe.Pointer
+	sbp            unsafe.Pointer
+	timeout        uint32
+	flags          uint32
+	packID         int32
+	usrPtr         unsafe.Pointer
+	status         uint8
+	maskedStatus   uint8
+	msgStatus      uint8
+	sbLenWr        uint8
+	hostStatus     uint16
+	driverStatus   uint16
+	resid          int32
+	duration       uint32
+	info           uint32
+}
+
+// Returns true if the ATA disk is in STANDBY or SLEEP power mode.
+// Sends CHECK POWER MODE like hdparm -C, the command doesn't spin the disk up.
+func ataStandby(device string) (bool, error) {
+	file, err := os.OpenFile(device, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	cdb := [16]byte{0: ataPassThrough16, 1: ataProtoNonData, 2: ataCheckCondition, 14: ataCheckPowerMode}
+	var sense [32]byte
+
+	header := sgIOHeader{
+		interfaceID:    'S',
+		dxferDirection: sgDxferNone,
+		cmdLen:         uint8(len(cdb)),
+		mxSbLen:        uint8(len(sense)),
+		cmdp:           unsafe.Pointer(&cdb[0]),
+		sbp:            unsafe.Pointer(&sense[0]),
+		timeout:        sgTimeoutMsec,
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), sgIO, uintptr(unsafe.Pointer(&header)))
+	if errno == syscall.EIO {
+		// Sleeping disks don't respond to commands
+		return true, nil
+	}
+
+	if errno != 0 {
+		return false, fmt.Errorf("check power mode: %w", errno)
+	}
+
+	mode, err := ataPowerMode(sense[:header.sbLenWr])
+	if err != nil {
+		return false, err
+	}
+
+	// 0x00 standby, 0x01 standby by standby timer,
+	// 0x40 and 0x41 NV cache power mode with spun down disk
+	switch mode {
+	case 0x00, 0x01, 0x40, 0x41:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// Returns power mode from sector count of ATA return descriptor in
+// descriptor format sense data or from fixed format sense data
+func ataPowerMode(sense []byte) (byte, error) {
+	switch {
+	case len(sense) >= 22 && sense[0]&0x7f == 0x72 && sense[8] == 0x09:
+		if sense[21]&ataStatusErr != 0 {
+			return 0, errors.New("check power mode: command aborted")
+		}
+
+		return sense[13], nil
+	case len(sense) >= 7 && sense[0]&0x7f == 0x70:
+		if sense[4]&ataStatusErr != 0 {
+			return 0, errors.New("check power mode: command aborted")
+		}
+
+		return sense[6], nil
+	default:
+		return 0, errors.New("check power mode: no ATA status")
+	}
+}
@@ -0,0 +1,32 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestATAPowerMode(t *testing.T) {
+	assert := assert.New(t)
+
+	descriptor := make([]byte, 22)
+	descriptor[0] = 0x72
+	descriptor[8] = 0x09
+	descriptor[13] = 0xff
+	descriptor[21] = 0x50
+
+	mode, err := ataPowerMode(descriptor)
+	assert.NoError(err)
+	assert.Equal(byte(0xff), mode)
+
+	descriptor[21] = 0x51
+	_, err = ataPowerMode(descriptor)
+	assert.Error(err)
+
+	mode, err = ataPowerMode([]byte{0x70, 0, 0, 0, 0x50, 0, 0x00})
+	assert.NoError(err)
+	assert.Equal(byte(0x00), mode)
+
+	_, err = ataPowerMode(nil)
+	assert.Error(err)
+}
@@ -0,0 +1,39 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+const defaultCommandTimeout = 5 * time.Second
+
+// Runs the command and returns its output.
+// The command is killed after the timeout, non zero exit code is an error.
+func RunCommand(args []string, timeout time.Duration) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	// Child processes can keep output open after the command is killed
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("command timeout %s", timeout)
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) != 0 {
+		return nil, fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+	}
+
+	return output, err
+}
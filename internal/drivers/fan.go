@@ -1,8 +1,72 @@
 package drivers
 
-import "github.com/IvanSafonov/fanctl/internal/models"
+import (
+	"strconv"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
 
 type FanDefaults struct {
 	Level  string
 	Repeat models.Seconds
 }
+
+// Fan state reported by the driver. Empty fields are not supported by the driver.
+type FanState struct {
+	Status string
+	Speed  *int
+	Level  string
+}
+
+// How the level affects the fan, used for kickstart and stall detection
+type LevelKind int
+
+const (
+	LevelUnknown LevelKind = iota
+	LevelStopped
+	LevelRunning
+)
+
+// Numeric levels: 0 stops the fan, greater values spin it.
+// Default for drivers without their own named levels.
+func NumericLevelKind(level string) LevelKind {
+	value, err := strconv.ParseFloat(level, 64)
+	switch {
+	case err != nil:
+		return LevelUnknown
+	case value == 0:
+		return LevelStopped
+	case value > 0:
+		return LevelRunning
+	default:
+		return LevelUnknown
+	}
+}
+
+// Returns all levels used in the fan configuration
+func fanLevels(conf config.Fan) []string {
+	var levels []string
+	kickstartLevel := ""
+	if conf.Kickstart != nil {
+		kickstartLevel = conf.Kickstart.Level
+	}
+
+	for _, level := range []string{conf.Level, conf.SuspendLevel, conf.EmergencyLevel, conf.FailsafeLevel, kickstartLevel} {
+		if level != "" {
+			levels = append(levels, level)
+		}
+	}
+
+	for _, level := range conf.Levels {
+		levels = append(levels, level.Level)
+	}
+
+	for _, profile := range conf.Profiles {
+		for _, level := range profile.Levels {
+			levels = append(levels, level.Level)
+		}
+	}
+
+	return levels
+}
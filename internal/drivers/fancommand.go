@@ -0,0 +1,78 @@
+package drivers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+const commandLevelPlaceholder = "{level}"
+
+// Fan controlled by external commands, e.g. vendor tools or scripts
+type FanCommand struct {
+	level          string
+	command        []string
+	initCommand    []string
+	restoreCommand []string
+	timeout        time.Duration
+}
+
+func NewFanCommand(conf config.Fan) *FanCommand {
+	timeout := defaultCommandTimeout
+	if conf.Timeout != nil {
+		timeout = conf.Timeout.Duration()
+	}
+
+	return &FanCommand{
+		level:          conf.Level,
+		command:        conf.Command,
+		initCommand:    conf.InitCommand,
+		restoreCommand: conf.RestoreCommand,
+		timeout:        timeout,
+	}
+}
+
+func (f *FanCommand) Init() error {
+	if len(f.initCommand) == 0 {
+		return nil
+	}
+
+	if _, err := RunCommand(f.initCommand, f.timeout); err != nil {
+		return fmt.Errorf("init command: %w", err)
+	}
+
+	return nil
+}
+
+// Runs the command with {level} replaced by the level
+func (f *FanCommand) SetLevel(level string) error {
+	args := make([]string, 0, len(f.command))
+	for _, arg := range f.command {
+		args = append(args, strings.ReplaceAll(arg, commandLevelPlaceholder, level))
+	}
+
+	_, err := RunCommand(args, f.timeout)
+	return err
+}
+
+func (f *FanCommand) Restore() error {
+	if len(f.restoreCommand) == 0 {
+		return nil
+	}
+
+	if _, err := RunCommand(f.restoreCommand, f.timeout); err != nil {
+		return fmt.Errorf("restore command: %w", err)
+	}
+
+	return nil
+}
+
+// Default level is the required configured level
+func (f *FanCommand) Defaults() FanDefaults {
+	return FanDefaults{
+		Level:  f.level,
+		Repeat: 60,
+	}
+}
@@ -0,0 +1,73 @@
+package drivers
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
+
+func TestFanCommand(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "fancmd")
+	require.NoError(err)
+	defer os.RemoveAll(tmpDir)
+
+	logFile := path.Join(tmpDir, "log")
+	script := path.Join(tmpDir, "fan.sh")
+	createFiles(t, tmpDir, map[string]string{
+		"fan.sh": "#!/bin/sh\necho \"$@\" >> " + logFile + "\n",
+	})
+	require.NoError(os.Chmod(script, 0755))
+
+	fan := NewFanCommand(config.Fan{
+		Command:        []string{script, "set", "speed={level}"},
+		InitCommand:    []string{script, "init"},
+		RestoreCommand: []string{script, "restore"},
+	})
+
+	assert.NoError(fan.Init())
+	assert.NoError(fan.SetLevel("3"))
+	assert.NoError(fan.Restore())
+
+	assertFileContent(t, logFile, "init\nset speed=3\nrestore\n")
+}
+
+func TestFanCommandErrors(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "fancmd")
+	require.NoError(err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"fail.sh":  "#!/bin/sh\necho 'no such fan' >&2\nexit 3\n",
+		"sleep.sh": "#!/bin/sh\nexec sleep 10\n",
+	})
+	require.NoError(os.Chmod(path.Join(tmpDir, "fail.sh"), 0755))
+	require.NoError(os.Chmod(path.Join(tmpDir, "sleep.sh"), 0755))
+
+	fan := NewFanCommand(config.Fan{
+		Command: []string{path.Join(tmpDir, "fail.sh")},
+	})
+	assert.EqualError(fan.SetLevel("1"), "exit status 3: no such fan")
+
+	fan = NewFanCommand(config.Fan{
+		Command: []string{path.Join(tmpDir, "sleep.sh")},
+		Timeout: models.SecondsPtr(0.1),
+	})
+	assert.EqualError(fan.SetLevel("1"), "command timeout 100ms")
+}
+
+func TestFanCommandDefaults(t *testing.T) {
+	fan := NewFanCommand(config.Fan{Level: "auto", Command: []string{"true"}})
+	assert.Equal(t, "auto", fan.Defaults().Level)
+}
@@ -0,0 +1,127 @@
+package drivers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+const coolingMaxLevel = "max"
+
+type FanCooling struct {
+	path   string
+	device string
+	levels []string
+
+	stateFile     string
+	maxState      int
+	originalState string
+}
+
+func NewFanCooling(conf config.Fan) *FanCooling {
+	return &FanCooling{
+		path:   cmp.Or(conf.Path, "/sys/class/thermal"),
+		device: conf.Device,
+		levels: fanLevels(conf),
+	}
+}
+
+// Finds cooling device by type and checks that all levels are within max state
+func (f *FanCooling) Init() error {
+	entries, err := os.ReadDir(f.path)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "cooling_device") {
+			continue
+		}
+
+		dir := path.Join(f.path, entry.Name())
+		deviceType, err := ReadSysFile(path.Join(dir, "type"))
+		if err != nil {
+			return fmt.Errorf("read type: %w", err)
+		}
+
+		if deviceType == f.device {
+			f.stateFile = path.Join(dir, "cur_state")
+			break
+		}
+	}
+
+	if f.stateFile == "" {
+		return errors.New("cooling device not found: " + f.device)
+	}
+
+	data, err := ReadSysFile(path.Join(path.Dir(f.stateFile), "max_state"))
+	if err != nil {
+		return fmt.Errorf("read max state: %w", err)
+	}
+
+	f.maxState, err = strconv.Atoi(data)
+	if err != nil {
+		return fmt.Errorf("parse max state: %w", err)
+	}
+
+	for _, level := range f.levels {
+		if _, err := f.state(level); err != nil {
+			return err
+		}
+	}
+
+	f.originalState, err = ReadSysFile(f.stateFile)
+	if err != nil {
+		return fmt.Errorf("read state: %w", err)
+	}
+
+	return nil
+}
+
+func (f *FanCooling) SetLevel(level string) error {
+	state, err := f.state(level)
+	if err != nil {
+		return err
+	}
+
+	return WriteSysFile(f.stateFile, strconv.Itoa(state))
+}
+
+// Restores original cooling device state
+func (f *FanCooling) Restore() error {
+	return WriteSysFile(f.stateFile, f.originalState)
+}
+
+func (f *FanCooling) Defaults() FanDefaults {
+	return FanDefaults{
+		Level:  coolingMaxLevel,
+		Repeat: 60,
+	}
+}
+
+func (f *FanCooling) state(level string) (int, error) {
+	if level == coolingMaxLevel {
+		return f.maxState, nil
+	}
+
+	state, err := strconv.Atoi(level)
+	if err != nil || state < 0 || state > f.maxState {
+		return 0, fmt.Errorf("level %s: must be within [0, %d] or max", level, f.maxState)
+	}
+
+	return state, nil
+}
+
+func (f *FanCooling) LevelKind(level string) LevelKind {
+	if level == coolingMaxLevel {
+		return LevelRunning
+	}
+
+	return NumericLevelKind(level)
+}
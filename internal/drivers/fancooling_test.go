@@ -0,0 +1,92 @@
+package drivers
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+func TestFanCooling(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "thermal")
+	require.NoError(err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"thermal_zone0/type":        "cpu-thermal",
+		"cooling_device0/type":      "Processor",
+		"cooling_device0/cur_state": "0",
+		"cooling_device0/max_state": "3",
+		"cooling_device1/type":      "pwm-fan",
+		"cooling_device1/cur_state": "1\n",
+		"cooling_device1/max_state": "4\n",
+	})
+
+	fan := NewFanCooling(config.Fan{
+		Path:   tmpDir,
+		Device: "pwm-fan",
+		Levels: []config.Level{{Level: "0"}, {Level: "4"}},
+	})
+
+	err = fan.Init()
+	require.NoError(err)
+
+	stateFile := path.Join(tmpDir, "cooling_device1/cur_state")
+
+	assert.NoError(fan.SetLevel("2"))
+	assertFileContent(t, stateFile, "2")
+
+	assert.NoError(fan.SetLevel("max"))
+	assertFileContent(t, stateFile, "4")
+
+	assert.Error(fan.SetLevel("5"))
+
+	assert.NoError(fan.Restore())
+	assertFileContent(t, stateFile, "1")
+}
+
+func TestFanCoolingLevelAboveMax(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "thermal")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"cooling_device0/type":      "pwm-fan",
+		"cooling_device0/cur_state": "0",
+		"cooling_device0/max_state": "4",
+	})
+
+	fan := NewFanCooling(config.Fan{
+		Path:   tmpDir,
+		Device: "pwm-fan",
+		Profiles: []config.ProfileLevels{
+			{Levels: []config.Level{{Level: "5"}}},
+		},
+	})
+
+	assert.EqualError(t, fan.Init(), "level 5: must be within [0, 4] or max")
+
+	for _, conf := range []config.Fan{
+		{EmergencyLevel: "6"},
+		{FailsafeLevel: "6"},
+		{Kickstart: &config.Kickstart{Level: "6"}},
+	} {
+		conf.Path = tmpDir
+		conf.Device = "pwm-fan"
+		assert.EqualError(t, NewFanCooling(conf).Init(), "level 6: must be within [0, 4] or max")
+	}
+}
+
+func TestFanCoolingLevelKind(t *testing.T) {
+	fan := NewFanCooling(config.Fan{})
+	assert.Equal(t, LevelRunning, fan.LevelKind("max"))
+	assert.Equal(t, LevelRunning, fan.LevelKind("2"))
+	assert.Equal(t, LevelStopped, fan.LevelKind("0"))
+}
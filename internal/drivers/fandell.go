@@ -0,0 +1,97 @@
+package drivers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+const dellAutoLevel = "auto"
+
+// Dell SMM fan. Uses dell_smm_hwmon pwm files, the driver maps pwm
+// values to 0-2 or 0-3 fan states depending on laptop model.
+type FanDell struct {
+	hwmon    *FanHwmon
+	maxLevel int
+	auto     bool
+}
+
+func NewFanDell(conf config.Fan) *FanDell {
+	conf.Sensor = cmp.Or(conf.Sensor, "dell_smm")
+
+	return &FanDell{
+		hwmon:    NewFanHwmon(conf),
+		maxLevel: cmp.Or(conf.MaxLevel, 2),
+	}
+}
+
+// Finds pwm files and disables BIOS automatic fan control.
+// dell_smm_hwmon pwm1_enable is write-only, so it isn't read.
+func (f *FanDell) Init() error {
+	if err := f.hwmon.find(); err != nil {
+		return err
+	}
+
+	if f.hwmon.enableFile == "" {
+		return nil
+	}
+
+	if err := WriteSysFile(f.hwmon.enableFile, hwmonPwmManual); err != nil {
+		return fmt.Errorf("disable auto mode: %w", err)
+	}
+
+	return nil
+}
+
+func (f *FanDell) SetLevel(level string) error {
+	if level == dellAutoLevel {
+		if err := f.setAuto(); err != nil {
+			return err
+		}
+
+		f.auto = true
+		return nil
+	}
+
+	value, err := strconv.Atoi(level)
+	if err != nil || value < 0 || value > f.maxLevel {
+		return fmt.Errorf("level %s: must be within [0, %d] or auto", level, f.maxLevel)
+	}
+
+	if f.auto {
+		if err := WriteSysFile(f.hwmon.enableFile, hwmonPwmManual); err != nil {
+			return fmt.Errorf("disable auto mode: %w", err)
+		}
+
+		f.auto = false
+	}
+
+	return f.hwmon.SetLevel(strconv.Itoa(value * 255 / f.maxLevel))
+}
+
+func (f *FanDell) State() (FanState, error) {
+	return f.hwmon.State()
+}
+
+// Enables BIOS automatic fan control
+func (f *FanDell) Restore() error {
+	return f.setAuto()
+}
+
+func (f *FanDell) Defaults() FanDefaults {
+	return FanDefaults{
+		Level:  dellAutoLevel,
+		Repeat: 60,
+	}
+}
+
+func (f *FanDell) setAuto() error {
+	if f.hwmon.enableFile == "" {
+		return errors.New("auto mode is not supported")
+	}
+
+	return WriteSysFile(f.hwmon.enableFile, hwmonPwmAuto)
+}
@@ -0,0 +1,62 @@
+package drivers
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/utils"
+)
+
+func TestFanDell(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "hwmon")
+	require.NoError(err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"hwmon4/name":        "dell_smm",
+		"hwmon4/pwm1":        "128",
+		"hwmon4/pwm1_enable": "2",
+		"hwmon4/fan1_input":  "2900",
+	})
+
+	pwmFile := path.Join(tmpDir, "hwmon4/pwm1")
+	enableFile := path.Join(tmpDir, "hwmon4/pwm1_enable")
+
+	// dell_smm_hwmon pwm1_enable is write-only
+	require.NoError(os.Chmod(enableFile, 0200))
+
+	fan := NewFanDell(config.Fan{Path: tmpDir, MaxLevel: 3})
+
+	err = fan.Init()
+	require.NoError(err)
+
+	require.NoError(os.Chmod(enableFile, 0600))
+	assertFileContent(t, enableFile, "1")
+
+	assert.NoError(fan.SetLevel("1"))
+	assertFileContent(t, pwmFile, "85")
+
+	assert.NoError(fan.SetLevel("auto"))
+	assertFileContent(t, enableFile, "2")
+
+	assert.NoError(fan.SetLevel("3"))
+	assertFileContent(t, enableFile, "1")
+	assertFileContent(t, pwmFile, "255")
+
+	assert.Error(fan.SetLevel("4"))
+
+	state, err := fan.State()
+	assert.NoError(err)
+	assert.Equal(utils.Ptr(2900), state.Speed)
+
+	assert.NoError(fan.Restore())
+	assertFileContent(t, enableFile, "2")
+}
@@ -0,0 +1,83 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+// Fan controlled by writing a value to a single file, e.g. fan_boost_mode
+type FanFile struct {
+	level    string
+	path     string
+	values   map[string]string
+	readBack bool
+
+	originalValue string
+}
+
+func NewFanFile(conf config.Fan) *FanFile {
+	return &FanFile{
+		level:    conf.Level,
+		path:     conf.Path,
+		values:   conf.Values,
+		readBack: conf.ReadBack,
+	}
+}
+
+func (f *FanFile) Init() error {
+	value, err := ReadSysFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.originalValue = value
+	return nil
+}
+
+// Writes value mapped from the level, or the level itself if there is no mapping
+func (f *FanFile) SetLevel(level string) error {
+	value := level
+	if len(f.values) != 0 {
+		var ok bool
+		if value, ok = f.values[level]; !ok {
+			return fmt.Errorf("level %s: has no value", level)
+		}
+	}
+
+	return WriteSysFile(f.path, value)
+}
+
+// Reads the value back and maps it to the level
+func (f *FanFile) State() (FanState, error) {
+	if !f.readBack {
+		return FanState{}, errors.ErrUnsupported
+	}
+
+	value, err := ReadSysFile(f.path)
+	if err != nil {
+		return FanState{}, err
+	}
+
+	for level, levelValue := range f.values {
+		if levelValue == value {
+			return FanState{Level: level}, nil
+		}
+	}
+
+	return FanState{Level: value}, nil
+}
+
+// Restores original file value
+func (f *FanFile) Restore() error {
+	return WriteSysFile(f.path, f.originalValue)
+}
+
+// Default level is the required configured level
+func (f *FanFile) Defaults() FanDefaults {
+	return FanDefaults{
+		Level:  f.level,
+		Repeat: 60,
+	}
+}
@@ -0,0 +1,72 @@
+package drivers
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+func TestFanFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	file, err := os.CreateTemp("", "fan_boost_mode")
+	require.NoError(err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("0\n")
+	require.NoError(err)
+
+	fan := NewFanFile(config.Fan{
+		Path:     file.Name(),
+		Values:   map[string]string{"normal": "0", "boost": "1", "silent": "2"},
+		ReadBack: true,
+	})
+
+	require.NoError(fan.Init())
+
+	assert.NoError(fan.SetLevel("silent"))
+	assertFileContent(t, file.Name(), "2")
+
+	state, err := fan.State()
+	assert.NoError(err)
+	assert.Equal(FanState{Level: "silent"}, state)
+
+	assert.Error(fan.SetLevel("turbo"))
+
+	require.NoError(os.WriteFile(file.Name(), []byte("5"), 0644))
+	state, err = fan.State()
+	assert.NoError(err)
+	assert.Equal(FanState{Level: "5"}, state)
+
+	assert.NoError(fan.Restore())
+	assertFileContent(t, file.Name(), "0")
+}
+
+func TestFanFileWithoutValues(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	file, err := os.CreateTemp("", "fan")
+	require.NoError(err)
+	defer os.Remove(file.Name())
+
+	fan := NewFanFile(config.Fan{Path: file.Name()})
+	require.NoError(fan.Init())
+
+	assert.NoError(fan.SetLevel("42"))
+	assertFileContent(t, file.Name(), "42")
+
+	_, err = fan.State()
+	assert.ErrorIs(err, errors.ErrUnsupported)
+}
+
+func TestFanFileDefaults(t *testing.T) {
+	fan := NewFanFile(config.Fan{Level: "normal", Path: "/sys/fan_boost_mode"})
+	assert.Equal(t, "normal", fan.Defaults().Level)
+}
@@ -0,0 +1,144 @@
+package drivers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+const (
+	hwmonPwmManual = "1"
+	hwmonPwmAuto   = "2"
+)
+
+type FanHwmon struct {
+	path   string
+	sensor string
+	index  int
+
+	pwmFile        string
+	enableFile     string
+	speedFile      string
+	originalEnable string
+}
+
+func NewFanHwmon(conf config.Fan) *FanHwmon {
+	index := 1
+	if conf.Index != nil {
+		index = *conf.Index
+	}
+
+	return &FanHwmon{
+		path:   cmp.Or(conf.Path, "/sys/class/hwmon"),
+		sensor: conf.Sensor,
+		index:  index,
+	}
+}
+
+// Finds pwm files and switches pwm control to manual mode
+func (f *FanHwmon) Init() error {
+	if err := f.find(); err != nil {
+		return err
+	}
+
+	if f.enableFile == "" {
+		return nil
+	}
+
+	originalEnable, err := ReadSysFile(f.enableFile)
+	if err != nil {
+		return fmt.Errorf("read pwm enable: %w", err)
+	}
+
+	// Manual mode is left by a previous run which wasn't stopped properly,
+	// restore automatic mode then
+	if originalEnable == hwmonPwmManual {
+		slog.Warn("pwm is in manual mode, automatic mode will be restored", "file", f.enableFile)
+		originalEnable = hwmonPwmAuto
+	}
+
+	if err := WriteSysFile(f.enableFile, hwmonPwmManual); err != nil {
+		return fmt.Errorf("write pwm enable: %w", err)
+	}
+
+	f.originalEnable = originalEnable
+	return nil
+}
+
+// Finds pwm, speed and pwm enable files
+func (f *FanHwmon) find() error {
+	dirs, err := findHwmonDirs(f.path, hwmonFilter{name: f.sensor})
+	if err != nil {
+		return err
+	}
+
+	pwm := fmt.Sprintf("pwm%d", f.index)
+	for _, dir := range dirs {
+		pwmFile := path.Join(dir, pwm)
+		if _, err := os.Stat(pwmFile); err == nil {
+			f.pwmFile = pwmFile
+			break
+		}
+	}
+
+	if f.pwmFile == "" {
+		return errors.New("pwm file not found: " + pwm)
+	}
+
+	speedFile := path.Join(path.Dir(f.pwmFile), fmt.Sprintf("fan%d_input", f.index))
+	if _, err := os.Stat(speedFile); err == nil {
+		f.speedFile = speedFile
+	}
+
+	enableFile := f.pwmFile + "_enable"
+	if _, err := os.Stat(enableFile); err == nil {
+		f.enableFile = enableFile
+	}
+
+	return nil
+}
+
+func (f *FanHwmon) SetLevel(level string) error {
+	return WriteSysFile(f.pwmFile, level)
+}
+
+// Reads fan speed from fanN_input file with the same number as pwm
+func (f *FanHwmon) State() (FanState, error) {
+	if f.speedFile == "" {
+		return FanState{}, errors.ErrUnsupported
+	}
+
+	data, err := ReadSysFile(f.speedFile)
+	if err != nil {
+		return FanState{}, err
+	}
+
+	speed, err := strconv.Atoi(data)
+	if err != nil {
+		return FanState{}, fmt.Errorf("parse speed: %w", err)
+	}
+
+	return FanState{Speed: &speed}, nil
+}
+
+// Restores original pwm control mode
+func (f *FanHwmon) Restore() error {
+	if f.enableFile == "" {
+		return nil
+	}
+
+	return WriteSysFile(f.enableFile, f.originalEnable)
+}
+
+func (f *FanHwmon) Defaults() FanDefaults {
+	return FanDefaults{
+		Level:  "255",
+		Repeat: 10,
+	}
+}
@@ -0,0 +1,97 @@
+package drivers
+
+import (
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/utils"
+)
+
+func TestFanHwmon(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "hwmon")
+	require.NoError(err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"hwmon2/name":        "coretemp",
+		"hwmon3/name":        "nct6798",
+		"hwmon3/pwm1":        "80",
+		"hwmon3/pwm1_enable": "5",
+		"hwmon3/pwm2":        "90",
+		"hwmon3/pwm2_enable": "5",
+		"hwmon3/fan2_input":  "1250",
+	})
+
+	fan := NewFanHwmon(config.Fan{Path: tmpDir, Sensor: "nct", Index: utils.Ptr(2)})
+
+	err = fan.Init()
+	require.NoError(err)
+	assertFileContent(t, path.Join(tmpDir, "hwmon3/pwm2_enable"), "1")
+	assertFileContent(t, path.Join(tmpDir, "hwmon3/pwm1_enable"), "5")
+
+	err = fan.SetLevel("120")
+	assert.NoError(err)
+	assertFileContent(t, path.Join(tmpDir, "hwmon3/pwm2"), "120")
+
+	state, err := fan.State()
+	assert.NoError(err)
+	assert.Equal(FanState{Speed: utils.Ptr(1250)}, state)
+
+	err = fan.Restore()
+	assert.NoError(err)
+	assertFileContent(t, path.Join(tmpDir, "hwmon3/pwm2_enable"), "5")
+}
+
+func TestFanHwmonManualRestore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "hwmon")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	// Left in manual mode by a killed process
+	createFiles(t, tmpDir, map[string]string{
+		"hwmon3/name":        "nct6798",
+		"hwmon3/pwm1":        "80",
+		"hwmon3/pwm1_enable": "1",
+	})
+
+	fan := NewFanHwmon(config.Fan{Path: tmpDir, Sensor: "nct"})
+	require.NoError(t, fan.Init())
+
+	assert.NoError(t, fan.Restore())
+	assertFileContent(t, path.Join(tmpDir, "hwmon3/pwm1_enable"), "2")
+}
+
+func TestFanHwmonNotFound(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "hwmon")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"hwmon3/name": "nct6798",
+		"hwmon3/pwm1": "80",
+	})
+
+	fan := NewFanHwmon(config.Fan{Path: tmpDir, Sensor: "nct", Index: utils.Ptr(3)})
+	assert.Error(t, fan.Init())
+
+	fan = NewFanHwmon(config.Fan{Path: tmpDir, Sensor: "nct"})
+	assert.NoError(t, fan.Init())
+
+	_, err = fan.State()
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+}
+
+func assertFileContent(t *testing.T, name, expected string) {
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(data))
+}
@@ -2,14 +2,25 @@ package drivers
 
 import (
 	"cmp"
+	"fmt"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
 )
 
 type FanThinkpad struct {
-	path   string
-	prefix string
+	path     string
+	prefix   string
+	watchdog int
+	level    string
+
+	// Selected fan on dual-fan laptops. Zero if the index isn't set,
+	// then commands aren't prefixed with select and control all fans.
+	index int
 }
 
 func NewFanThinkpad(conf config.Fan) *FanThinkpad {
@@ -18,9 +29,21 @@ func NewFanThinkpad(conf config.Fan) *FanThinkpad {
 		prefix = ""
 	}
 
+	var watchdog int
+	if conf.Watchdog != nil {
+		watchdog = int(math.Ceil(float64(*conf.Watchdog)))
+	}
+
+	var index int
+	if conf.Index != nil {
+		index = *conf.Index
+	}
+
 	return &FanThinkpad{
-		path:   cmp.Or(conf.Path, "/proc/acpi/ibm/fan"),
-		prefix: prefix,
+		path:     cmp.Or(conf.Path, "/proc/acpi/ibm/fan"),
+		prefix:   prefix,
+		watchdog: watchdog,
+		index:    index,
 	}
 }
 
@@ -31,27 +54,136 @@ func (f *FanThinkpad) Init() error {
 	}
 
 	file.Close()
+
+	// The driver switches the fan to auto mode if there are no fan commands
+	// during the watchdog timeout. Any fan command rearms it.
+	if f.watchdog != 0 {
+		if err := f.write(fmt.Sprintf("watchdog %d", f.watchdog)); err != nil {
+			return fmt.Errorf("set watchdog: %w", err)
+		}
+	}
+
 	return nil
 }
 
 func (f *FanThinkpad) SetLevel(level string) error {
+	if err := f.write(f.prefix + level); err != nil {
+		return err
+	}
+
+	f.level = level
+	return nil
+}
+
+// Disables watchdog
+func (f *FanThinkpad) Restore() error {
+	if f.watchdog == 0 {
+		return nil
+	}
+
+	return f.write("watchdog 0")
+}
+
+func (f *FanThinkpad) write(command string) error {
+	unlock := lockFile(f.path)
+	defer unlock()
+
+	return f.writeLocked(command)
+}
+
+// Writes commands to the acpi file. Selects the fan first on multi-fan laptops.
+// Multiple fans share the same file, so it has to be locked.
+func (f *FanThinkpad) writeLocked(commands ...string) error {
 	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	_, err = file.WriteString(f.prefix + level)
-	if err != nil {
-		return err
+	if f.index != 0 {
+		commands = append([]string{fmt.Sprintf("select fan %d", f.index)}, commands...)
+	}
+
+	for _, command := range commands {
+		if _, err := file.WriteString(command); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// Reads fan status, speed and level from the acpi file
+func (f *FanThinkpad) State() (FanState, error) {
+	var state FanState
+
+	unlock := lockFile(f.path)
+	defer unlock()
+
+	if f.index != 0 {
+		if err := f.writeLocked(); err != nil {
+			return state, err
+		}
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return state, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "status":
+			state.Status = value
+		case "speed":
+			speed, err := strconv.Atoi(value)
+			if err != nil {
+				return state, fmt.Errorf("parse speed: %w", err)
+			}
+
+			state.Speed = &speed
+		case "level":
+			state.Level = value
+		}
+	}
+
+	// Raw levels can be any command, there is nothing to compare with
+	if f.prefix == "" {
+		state.Level = ""
+	}
+
+	// The driver reports full-speed level as disengaged
+	if state.Level == "disengaged" && f.level == "full-speed" {
+		state.Level = f.level
+	}
+
+	return state, nil
+}
+
 func (f *FanThinkpad) Defaults() FanDefaults {
+	repeat := models.Seconds(60)
+	if f.watchdog != 0 {
+		repeat = min(repeat, models.Seconds(max(f.watchdog/2, 1)))
+	}
+
 	return FanDefaults{
 		Level:  "auto",
-		Repeat: 60,
+		Repeat: repeat,
+	}
+}
+
+func (f *FanThinkpad) LevelKind(level string) LevelKind {
+	switch level {
+	case "full-speed", "disengaged":
+		return LevelRunning
 	}
+
+	return NumericLevelKind(strings.TrimPrefix(level, "level "))
 }
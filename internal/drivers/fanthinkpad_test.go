@@ -2,12 +2,17 @@ package drivers
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+	"github.com/IvanSafonov/fanctl/internal/utils"
 )
 
 func TestFanThinkpadInit(t *testing.T) {
@@ -40,3 +45,95 @@ func TestFanThinkpadSetLevel(t *testing.T) {
 
 	assert.Equal("level 1", string(data))
 }
+
+func TestFanThinkpadState(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	acpiFile, err := os.CreateTemp("", "acpi.fan")
+	require.NoError(err)
+	defer os.Remove(acpiFile.Name())
+
+	_, err = acpiFile.WriteString("status:\t\tenabled\n" +
+		"speed:\t\t2430\n" +
+		"level:\t\tdisengaged\n" +
+		"commands:\tlevel <level> (<level> is 0-7, auto, disengaged, full-speed)\n" +
+		"commands:\tenable, disable\n")
+	require.NoError(err)
+
+	fan := NewFanThinkpad(config.Fan{Path: acpiFile.Name()})
+	fan.level = "full-speed"
+
+	state, err := fan.State()
+	assert.NoError(err)
+	assert.Equal(FanState{Status: "enabled", Speed: utils.Ptr(2430), Level: "full-speed"}, state)
+}
+
+func TestFanThinkpadWatchdog(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	acpiFile, err := os.CreateTemp("", "acpi.fan")
+	require.NoError(err)
+	defer os.Remove(acpiFile.Name())
+
+	fan := NewFanThinkpad(config.Fan{Path: acpiFile.Name(), Watchdog: models.SecondsPtr(30)})
+	assert.Equal(models.Seconds(15), fan.Defaults().Repeat)
+
+	err = fan.Init()
+	assert.NoError(err)
+
+	err = fan.SetLevel("1")
+	assert.NoError(err)
+
+	err = fan.Restore()
+	assert.NoError(err)
+
+	data, err := os.ReadFile(acpiFile.Name())
+	assert.NoError(err)
+
+	assert.Equal("watchdog 30level 1watchdog 0", string(data))
+}
+
+func TestFanThinkpadMultipleFans(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	acpiFile, err := os.CreateTemp("", "acpi.fan")
+	require.NoError(err)
+	defer os.Remove(acpiFile.Name())
+
+	fan1 := NewFanThinkpad(config.Fan{Path: acpiFile.Name(), Index: utils.Ptr(1)})
+	fan2 := NewFanThinkpad(config.Fan{Path: acpiFile.Name(), Index: utils.Ptr(2)})
+
+	var wg sync.WaitGroup
+	for _, fan := range []*FanThinkpad{fan1, fan2} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				assert.NoError(fan.SetLevel(strconv.Itoa(fan.index)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(acpiFile.Name())
+	assert.NoError(err)
+
+	content := string(data)
+	content = strings.ReplaceAll(content, "select fan 1level 1", "")
+	content = strings.ReplaceAll(content, "select fan 2level 2", "")
+	assert.Empty(content)
+}
+
+func TestFanThinkpadLevelKind(t *testing.T) {
+	assert := assert.New(t)
+
+	fan := NewFanThinkpad(config.Fan{})
+	assert.Equal(LevelRunning, fan.LevelKind("full-speed"))
+	assert.Equal(LevelRunning, fan.LevelKind("disengaged"))
+	assert.Equal(LevelRunning, fan.LevelKind("level 3"))
+	assert.Equal(LevelStopped, fan.LevelKind("0"))
+	assert.Equal(LevelUnknown, fan.LevelKind("auto"))
+}
@@ -3,11 +3,24 @@ package drivers
 import (
 	"bytes"
 	"os"
+	"sync"
 	"syscall"
 )
 
 const sysFileBufferSize = 128
 
+var fileLocks sync.Map
+
+// Locks the file for multiple drivers which use it. Returns unlock function.
+// The lock works only inside the process, other programs writing the file
+// at the same time aren't synchronized with it.
+func lockFile(name string) func() {
+	lock, _ := fileLocks.LoadOrStore(name, &sync.Mutex{})
+	mutex := lock.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
+
 func ReadSysFile(name string) (string, error) {
 	f, err := os.Open(name)
 	if err != nil {
@@ -23,3 +36,14 @@ func ReadSysFile(name string) (string, error) {
 
 	return string(bytes.TrimSpace(b[:n])), nil
 }
+
+func WriteSysFile(name, value string) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(value)
+	return err
+}
@@ -0,0 +1,75 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+// Selects hwmon directories by name, name regex and device.
+// Empty filter fields match any directory.
+type hwmonFilter struct {
+	name      string
+	nameRegex *regexp.Regexp
+	device    string
+}
+
+// Returns hwmon directories matching the filter
+func findHwmonDirs(root string, filter hwmonFilter) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("read dir: %w", err)
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		dir := path.Join(root, entry.Name())
+		nameFile := path.Join(dir, "name")
+		if _, err := os.Stat(nameFile); os.IsNotExist(err) {
+			continue
+		}
+
+		dirName, err := ReadSysFile(nameFile)
+		if err != nil {
+			return nil, fmt.Errorf("read sensor name: %w", err)
+		}
+
+		if !strings.Contains(dirName, filter.name) {
+			continue
+		}
+
+		if filter.nameRegex != nil && !filter.nameRegex.MatchString(dirName) {
+			continue
+		}
+
+		if filter.device != "" && !strings.Contains(hwmonDevice(dir), filter.device) {
+			continue
+		}
+
+		dirs = append(dirs, dir)
+	}
+
+	return dirs, nil
+}
+
+// Returns resolved path of the hwmon device symlink,
+// e.g. /sys/devices/pci0000:00/0000:00:18.3. Empty if there is no device.
+func hwmonDevice(dir string) string {
+	device, err := filepath.EvalSymlinks(path.Join(dir, "device"))
+	if err != nil {
+		return ""
+	}
+
+	return device
+}
+
+// Returns true if the error means that hwmon files were removed
+func isDeviceGone(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENODEV)
+}
@@ -0,0 +1,71 @@
+package drivers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+const (
+	PowerSupplyProfileAC      = "ac"
+	PowerSupplyProfileBattery = "battery"
+)
+
+// Profile from AC adapter state, ac or battery
+type ProfilePowerSupply struct {
+	path        string
+	onlineFiles []string
+}
+
+func NewProfilePowerSupply(conf config.Profile) *ProfilePowerSupply {
+	return &ProfilePowerSupply{
+		path: cmp.Or(conf.Path, "/sys/class/power_supply"),
+	}
+}
+
+// Finds AC adapters
+func (p *ProfilePowerSupply) Init() error {
+	entries, err := os.ReadDir(p.path)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		supplyDir := path.Join(p.path, entry.Name())
+		supplyType, err := ReadSysFile(path.Join(supplyDir, "type"))
+		if err != nil {
+			return fmt.Errorf("read type: %w", err)
+		}
+
+		if supplyType != "Mains" {
+			continue
+		}
+
+		p.onlineFiles = append(p.onlineFiles, path.Join(supplyDir, "online"))
+	}
+
+	if len(p.onlineFiles) == 0 {
+		return errors.New("AC adapter not found")
+	}
+
+	return nil
+}
+
+func (p *ProfilePowerSupply) State() (string, error) {
+	for _, onlineFile := range p.onlineFiles {
+		online, err := ReadSysFile(onlineFile)
+		if err != nil {
+			return "", err
+		}
+
+		if online == "1" {
+			return PowerSupplyProfileAC, nil
+		}
+	}
+
+	return PowerSupplyProfileBattery, nil
+}
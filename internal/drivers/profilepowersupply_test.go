@@ -0,0 +1,42 @@
+package drivers
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+func TestProfilePowerSupply(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "power_supply")
+	require.NoError(err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"AC/type":          "Mains",
+		"AC/online":        "0",
+		"BAT0/type":        "Battery",
+		"BAT0/capacity":    "85",
+		"ucsi-source/type": "USB",
+	})
+
+	p := NewProfilePowerSupply(config.Profile{Path: tmpDir})
+	require.NoError(p.Init())
+
+	state, err := p.State()
+	assert.NoError(err)
+	assert.Equal("battery", state)
+
+	require.NoError(os.WriteFile(path.Join(tmpDir, "AC/online"), []byte("1\n"), 0644))
+
+	state, err = p.State()
+	assert.NoError(err)
+	assert.Equal("ac", state)
+}
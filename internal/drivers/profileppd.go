@@ -0,0 +1,193 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+type ppdService struct {
+	name string
+	path dbus.ObjectPath
+}
+
+// power-profiles-daemon names, the new one first
+var ppdServices = []ppdService{
+	{name: "org.freedesktop.UPower.PowerProfiles", path: "/org/freedesktop/UPower/PowerProfiles"},
+	{name: "net.hadess.PowerProfiles", path: "/net/hadess/PowerProfiles"},
+}
+
+// Profile from power-profiles-daemon: power-saver, balanced or performance.
+// Subscribes to ActiveProfile changes on the system bus and reads
+// the profile again when the daemon restarts.
+type ProfilePPD struct {
+	address string
+
+	mutex   sync.Mutex
+	conn    *dbus.Conn
+	profile string
+}
+
+func NewProfilePPD(conf config.Profile) *ProfilePPD {
+	return &ProfilePPD{
+		address: conf.Address,
+	}
+}
+
+func (p *ProfilePPD) Init() error {
+	return p.connect()
+}
+
+// Returns the last active profile. Connects again if the connection is lost.
+func (p *ProfilePPD) State() (string, error) {
+	p.mutex.Lock()
+	connected := p.conn != nil
+	p.mutex.Unlock()
+
+	if !connected {
+		if err := p.connect(); err != nil {
+			return "", err
+		}
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.profile, nil
+}
+
+// Connects to the bus, reads active profile and subscribes to its changes
+func (p *ProfilePPD) connect() error {
+	var conn *dbus.Conn
+	var err error
+	if p.address != "" {
+		conn, err = dbus.Connect(p.address)
+	} else {
+		conn, err = dbus.ConnectSystemBus()
+	}
+
+	if err != nil {
+		return fmt.Errorf("connect dbus: %w", err)
+	}
+
+	service, profile, err := findPPDService(conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	err = conn.AddMatchSignal(
+		dbus.WithMatchSender(service.name),
+		dbus.WithMatchObjectPath(service.path),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+	)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("add match signal: %w", err)
+	}
+
+	err = conn.AddMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(0, service.name),
+	)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("add match signal: %w", err)
+	}
+
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+
+	p.mutex.Lock()
+	p.conn = conn
+	p.profile = profile
+	p.mutex.Unlock()
+
+	go p.watch(conn, service, signals)
+	return nil
+}
+
+// Updates active profile from PropertiesChanged signals until the connection is closed.
+// Reads the profile when the service gets a new owner, the last one is kept while there is no owner.
+func (p *ProfilePPD) watch(conn *dbus.Conn, service ppdService, signals chan *dbus.Signal) {
+	for signal := range signals {
+		switch signal.Name {
+		case "org.freedesktop.DBus.Properties.PropertiesChanged":
+			if len(signal.Body) < 2 {
+				continue
+			}
+
+			changed, ok := signal.Body[1].(map[string]dbus.Variant)
+			if !ok {
+				continue
+			}
+
+			if variant, ok := changed["ActiveProfile"]; ok {
+				p.setProfile(variant)
+			}
+		case "org.freedesktop.DBus.NameOwnerChanged":
+			if len(signal.Body) < 3 {
+				continue
+			}
+
+			if owner, _ := signal.Body[2].(string); owner == "" {
+				slog.Warn("power-profiles-daemon stopped")
+				continue
+			}
+
+			variant, err := conn.Object(service.name, service.path).GetProperty(service.name + ".ActiveProfile")
+			if err != nil {
+				slog.Error("failed to read power-profiles-daemon profile", "error", err)
+				continue
+			}
+
+			p.setProfile(variant)
+		}
+	}
+
+	slog.Warn("power-profiles-daemon connection closed")
+
+	p.mutex.Lock()
+	if p.conn == conn {
+		p.conn = nil
+	}
+	p.mutex.Unlock()
+}
+
+func (p *ProfilePPD) setProfile(variant dbus.Variant) {
+	if profile, ok := variant.Value().(string); ok {
+		p.mutex.Lock()
+		p.profile = profile
+		p.mutex.Unlock()
+	}
+}
+
+// Returns the first available service and its active profile
+func findPPDService(conn *dbus.Conn) (ppdService, string, error) {
+	var errs []error
+
+	for _, service := range ppdServices {
+		variant, err := conn.Object(service.name, service.path).GetProperty(service.name + ".ActiveProfile")
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		profile, ok := variant.Value().(string)
+		if !ok {
+			errs = append(errs, errors.New("wrong ActiveProfile type: "+variant.Signature().String()))
+			continue
+		}
+
+		return service, profile, nil
+	}
+
+	return ppdService{}, "", fmt.Errorf("power-profiles-daemon not found: %w", errors.Join(errs...))
+}
@@ -0,0 +1,139 @@
+package drivers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+const testBusConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:path=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+func TestProfilePPD(t *testing.T) {
+	assert := assert.New(t)
+
+	address := startTestBus(t)
+
+	s := NewProfilePPD(config.Profile{Address: address})
+	assert.ErrorContains(s.Init(), "power-profiles-daemon not found")
+
+	conn, props := exportTestPPD(t, address, "net.hadess.PowerProfiles", "/net/hadess/PowerProfiles", "balanced")
+
+	require.NoError(t, s.Init())
+
+	profile, err := s.State()
+	assert.NoError(err)
+	assert.Equal("balanced", profile)
+
+	props.SetMust("net.hadess.PowerProfiles", "ActiveProfile", "performance")
+
+	assert.Eventually(func() bool {
+		profile, err := s.State()
+		return err == nil && profile == "performance"
+	}, time.Second, 10*time.Millisecond)
+
+	// Daemon restart
+	require.NoError(t, conn.Close())
+	exportTestPPD(t, address, "net.hadess.PowerProfiles", "/net/hadess/PowerProfiles", "power-saver")
+
+	assert.Eventually(func() bool {
+		profile, err := s.State()
+		return err == nil && profile == "power-saver"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestProfilePPDUPower(t *testing.T) {
+	assert := assert.New(t)
+
+	address := startTestBus(t)
+	_, props := exportTestPPD(t, address, "org.freedesktop.UPower.PowerProfiles", "/org/freedesktop/UPower/PowerProfiles",
+		"power-saver")
+
+	s := NewProfilePPD(config.Profile{Address: address})
+	require.NoError(t, s.Init())
+
+	profile, err := s.State()
+	assert.NoError(err)
+	assert.Equal("power-saver", profile)
+
+	props.SetMust("org.freedesktop.UPower.PowerProfiles", "ActiveProfile", "balanced")
+
+	assert.Eventually(func() bool {
+		profile, err := s.State()
+		return err == nil && profile == "balanced"
+	}, time.Second, 10*time.Millisecond)
+}
+
+// Starts private dbus-daemon and returns its address
+func startTestBus(t *testing.T) string {
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not found")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "dbus")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	configFile := path.Join(tmpDir, "bus.conf")
+	socket := path.Join(tmpDir, "bus")
+	err = os.WriteFile(configFile, []byte(fmt.Sprintf(testBusConfig, socket)), 0644)
+	require.NoError(t, err)
+
+	cmd := exec.Command(daemon, "--config-file="+configFile, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+
+	return strings.TrimSpace(address)
+}
+
+// Exports fake power-profiles-daemon object with ActiveProfile property
+func exportTestPPD(t *testing.T, address, name string, objectPath dbus.ObjectPath, profile string) (*dbus.Conn, *prop.Properties) {
+	conn, err := dbus.Connect(address)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	props, err := prop.Export(conn, objectPath, prop.Map{
+		name: {
+			"ActiveProfile": {Value: profile, Writable: true, Emit: prop.EmitTrue},
+		},
+	})
+	require.NoError(t, err)
+
+	reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
+	require.NoError(t, err)
+	require.Equal(t, dbus.RequestNameReplyPrimaryOwner, reply)
+
+	return conn, props
+}
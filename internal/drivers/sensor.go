@@ -0,0 +1,175 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
+
+// Sensor input files with numeric values.
+// Applies factor and constant to each value, rejects implausible values
+// and selects one of the rest.
+type sensorInputs struct {
+	files      []string
+	factor     float64
+	add        float64
+	selectFunc func([]float64) float64
+	guard      sensorGuard
+}
+
+func newSensorInputs(conf config.Sensor, defaultFactor float64) sensorInputs {
+	factor := defaultFactor
+	if conf.Factor != nil {
+		factor = *conf.Factor
+	}
+
+	add := 0.0
+	if conf.Add != nil {
+		add = *conf.Add
+	}
+
+	return sensorInputs{
+		factor:     factor,
+		add:        add,
+		selectFunc: models.SelectFunc(conf.Select),
+		guard:      newSensorGuard(conf),
+	}
+}
+
+func (s *sensorInputs) Value() (float64, error) {
+	values := make([]float64, 0, len(s.files))
+
+	for _, inputFile := range s.files {
+		value, err := s.read(inputFile)
+		if err != nil {
+			return 0, err
+		}
+
+		if s.guard.Accept(inputFile, value) {
+			values = append(values, value)
+		}
+	}
+
+	if len(values) == 0 {
+		return 0, errRejected
+	}
+
+	result := s.selectFunc(values)
+	return result, nil
+}
+
+// Reads the input file and applies factor and constant
+func (s *sensorInputs) read(inputFile string) (float64, error) {
+	data, err := ReadSysFile(inputFile)
+	if err != nil {
+		return 0, fmt.Errorf("read input: %w", err)
+	}
+
+	value, err := strconv.ParseFloat(data, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse input: %w", err)
+	}
+
+	return value*s.factor + s.add, nil
+}
+
+var errRejected = errors.New("all input values are rejected")
+
+// Sensor has no value for now, e.g. disk is in standby. It's not a failure.
+var ErrSensorIdle = errors.New("sensor is idle")
+
+// Sensor has no new value since the last read, e.g. cached command result.
+// The last value or error stays in effect.
+var ErrSensorUnchanged = errors.New("sensor value is unchanged")
+
+// Rejects values out of the valid range and values that changed faster
+// than max step per second since the last accepted value of the input.
+// Counts consecutive rejections of each input, the first one of a burst is logged as a warning.
+type sensorGuard struct {
+	validMin *float64
+	validMax *float64
+	maxStep  float64
+
+	last     map[string]guardSample
+	rejected map[string]int
+}
+
+type guardSample struct {
+	value float64
+	time  time.Time
+}
+
+func newSensorGuard(conf config.Sensor) sensorGuard {
+	var maxStep float64
+	if conf.MaxStep != nil {
+		maxStep = *conf.MaxStep
+	}
+
+	return sensorGuard{
+		validMin: conf.ValidMin,
+		validMax: conf.ValidMax,
+		maxStep:  maxStep,
+		last:     make(map[string]guardSample),
+		rejected: make(map[string]int),
+	}
+}
+
+// Returns false if the input value is implausible
+func (g *sensorGuard) Accept(input string, value float64) bool {
+	now := time.Now()
+
+	var reason string
+	switch {
+	case g.validMin != nil && value < *g.validMin:
+		reason = "less than validMin"
+	case g.validMax != nil && value > *g.validMax:
+		reason = "greater than validMax"
+	case g.maxStep > 0:
+		last, ok := g.last[input]
+		if ok && math.Abs(value-last.value) > g.maxStep*now.Sub(last.time).Seconds() {
+			reason = "greater than maxStep"
+		}
+	}
+
+	if reason != "" {
+		g.rejected[input]++
+		if g.rejected[input] == 1 {
+			slog.Warn("sensor value rejected", "input", input, "value", value, "reason", reason)
+		} else {
+			slog.Debug("sensor value rejected", "input", input, "value", value, "reason", reason,
+				"rejected", g.rejected[input])
+		}
+
+		return false
+	}
+
+	if g.rejected[input] != 0 {
+		slog.Info("sensor value accepted", "input", input, "value", value, "rejected", g.rejected[input])
+		delete(g.rejected, input)
+	}
+
+	if g.maxStep > 0 {
+		g.last[input] = guardSample{value: value, time: now}
+	}
+
+	return true
+}
+
+// Matches the name with shell pattern if it has wildcards,
+// otherwise checks that the name contains the pattern
+func matchName(pattern, name string) bool {
+	if strings.ContainsAny(pattern, "*?[") {
+		matched, _ := filepath.Match(pattern, name)
+		return matched
+	}
+
+	return strings.Contains(name, pattern)
+}
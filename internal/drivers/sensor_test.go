@@ -0,0 +1,75 @@
+package drivers
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+	"github.com/IvanSafonov/fanctl/internal/utils"
+)
+
+func TestSensorInputsValidRange(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "hwmon")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"temp1_input": "45000",
+		"temp2_input": "-128000",
+		"temp3_input": "255000",
+	})
+
+	s := newSensorInputs(config.Sensor{
+		Select:   models.SelectFuncMax,
+		ValidMin: utils.Ptr(0.0),
+		ValidMax: utils.Ptr(150.0),
+	}, 0.001)
+	s.files = []string{
+		path.Join(tmpDir, "temp1_input"),
+		path.Join(tmpDir, "temp2_input"),
+		path.Join(tmpDir, "temp3_input"),
+	}
+
+	value, err := s.Value()
+	assert.NoError(err)
+	assert.Equal(45.0, value)
+	assert.Equal(0, s.guard.rejected[s.files[0]])
+	assert.Equal(1, s.guard.rejected[s.files[1]])
+	assert.Equal(1, s.guard.rejected[s.files[2]])
+
+	s.files = s.files[1:]
+	_, err = s.Value()
+	assert.ErrorContains(err, "all input values are rejected")
+	assert.Equal(2, s.guard.rejected[s.files[0]])
+}
+
+func TestSensorGuardMaxStep(t *testing.T) {
+	assert := assert.New(t)
+
+	g := newSensorGuard(config.Sensor{MaxStep: utils.Ptr(5.0)})
+
+	assert.True(g.Accept("temp1", 40))
+
+	g.last["temp1"] = guardSample{value: 40, time: time.Now().Add(-time.Second)}
+	assert.False(g.Accept("temp1", 100))
+	assert.Equal(1, g.rejected["temp1"])
+	assert.True(g.Accept("temp1", 44))
+	assert.Zero(g.rejected["temp1"])
+
+	g.last["temp1"] = guardSample{value: 44, time: time.Now().Add(-20 * time.Second)}
+	assert.True(g.Accept("temp1", 100))
+	assert.Zero(g.rejected["temp1"])
+
+	// New burst is counted from the start
+	g.last["temp1"] = guardSample{value: 100, time: time.Now().Add(-time.Second)}
+	assert.False(g.Accept("temp1", 20))
+	assert.Equal(1, g.rejected["temp1"])
+}
@@ -0,0 +1,173 @@
+package drivers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+// Runs a command and parses a number from its output. The command runs
+// in background not more often than the interval, the last result is cached.
+// An error of a run is returned once, then ErrSensorUnchanged until the next run.
+type SensorCommand struct {
+	command  []string
+	regex    *regexp.Regexp
+	jsonPath []string
+	factor   float64
+	add      float64
+	interval time.Duration
+	timeout  time.Duration
+
+	mutex   sync.Mutex
+	running bool
+	updated time.Time
+	value   float64
+	err     error
+
+	errReported bool
+}
+
+func NewSensorCommand(conf config.Sensor) *SensorCommand {
+	inputs := newSensorInputs(conf, 1)
+
+	s := &SensorCommand{
+		command:  conf.Command,
+		factor:   inputs.factor,
+		add:      inputs.add,
+		interval: 10 * time.Second,
+		timeout:  defaultCommandTimeout,
+	}
+
+	if conf.Regex != "" {
+		s.regex = regexp.MustCompile(conf.Regex)
+	}
+
+	if conf.JSONPath != "" {
+		s.jsonPath = strings.Split(conf.JSONPath, ".")
+	}
+
+	if conf.Interval != nil {
+		s.interval = conf.Interval.Duration()
+	}
+
+	if conf.Timeout != nil {
+		s.timeout = conf.Timeout.Duration()
+	}
+
+	return s
+}
+
+// Runs the command the first time and checks the result
+func (s *SensorCommand) Init() error {
+	s.update()
+	return s.err
+}
+
+// Returns the cached value and starts the command in background if the value is outdated
+func (s *SensorCommand) Value() (float64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.running && time.Since(s.updated) >= s.interval {
+		s.running = true
+		go s.update()
+	}
+
+	if s.err != nil && s.errReported {
+		return 0, ErrSensorUnchanged
+	}
+
+	s.errReported = s.err != nil
+	return s.value, s.err
+}
+
+func (s *SensorCommand) update() {
+	value, err := s.run()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.value = value
+	s.err = err
+	s.errReported = false
+	s.running = false
+	s.updated = time.Now()
+}
+
+func (s *SensorCommand) run() (float64, error) {
+	output, err := RunCommand(s.command, s.timeout)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := s.parse(output)
+	if err != nil {
+		return 0, fmt.Errorf("parse output: %w", err)
+	}
+
+	return value*s.factor + s.add, nil
+}
+
+func (s *SensorCommand) parse(output []byte) (float64, error) {
+	if s.jsonPath != nil {
+		return parseJSONPath(output, s.jsonPath)
+	}
+
+	text := string(output)
+	if s.regex != nil {
+		match := s.regex.FindStringSubmatch(text)
+		if match == nil {
+			return 0, errors.New("regex doesn't match")
+		}
+
+		// The first group or the whole match
+		text = match[0]
+		if len(match) > 1 {
+			text = match[1]
+		}
+	}
+
+	return strconv.ParseFloat(strings.TrimSpace(text), 64)
+}
+
+// Finds a number in json by dot separated path, numbers are array indexes
+func parseJSONPath(data []byte, jsonPath []string) (float64, error) {
+	var node any
+	if err := json.Unmarshal(data, &node); err != nil {
+		return 0, err
+	}
+
+	for _, key := range jsonPath {
+		switch typed := node.(type) {
+		case map[string]any:
+			var ok bool
+			if node, ok = typed[key]; !ok {
+				return 0, fmt.Errorf("key %s not found", key)
+			}
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(typed) {
+				return 0, fmt.Errorf("wrong index %s", key)
+			}
+			node = typed[idx]
+		default:
+			return 0, fmt.Errorf("key %s not found", key)
+		}
+	}
+
+	switch typed := node.(type) {
+	case float64:
+		return typed, nil
+	case string:
+		return strconv.ParseFloat(typed, 64)
+	}
+
+	return 0, errors.New("value is not a number")
+}
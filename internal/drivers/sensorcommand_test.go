@@ -0,0 +1,121 @@
+package drivers
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+	"github.com/IvanSafonov/fanctl/internal/utils"
+)
+
+func TestSensorCommandRegex(t *testing.T) {
+	assert := assert.New(t)
+
+	s := NewSensorCommand(config.Sensor{
+		Command: []string{"echo", "194 Temperature_Celsius 0x0022 036 052 000 Old_age Always - 36"},
+		Regex:   `Temperature_Celsius.* (\d+)`,
+		Add:     utils.Ptr(0.5),
+	})
+	assert.NoError(s.Init())
+
+	value, err := s.Value()
+	assert.NoError(err)
+	assert.Equal(36.5, value)
+}
+
+func TestSensorCommandJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	s := NewSensorCommand(config.Sensor{
+		Command:  []string{"echo", `{"temperature": {"current": 41}, "sensors": [{"value": "12.5"}]}`},
+		JSONPath: "temperature.current",
+	})
+	assert.NoError(s.Init())
+
+	value, err := s.Value()
+	assert.NoError(err)
+	assert.Equal(41.0, value)
+
+	s.jsonPath = []string{"sensors", "0", "value"}
+	assert.NoError(s.Init())
+
+	value, err = s.Value()
+	assert.NoError(err)
+	assert.Equal(12.5, value)
+
+	s.jsonPath = []string{"sensors", "1", "value"}
+	assert.EqualError(s.Init(), "parse output: wrong index 1")
+}
+
+func TestSensorCommandCache(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "sensorcmd")
+	require.NoError(err)
+	defer os.RemoveAll(tmpDir)
+
+	valueFile := path.Join(tmpDir, "value")
+	createFiles(t, tmpDir, map[string]string{"value": "1"})
+
+	s := NewSensorCommand(config.Sensor{
+		Command:  []string{"cat", valueFile},
+		Interval: models.SecondsPtr(1000),
+	})
+	require.NoError(s.Init())
+
+	require.NoError(os.WriteFile(valueFile, []byte("2"), 0644))
+
+	// Cached value
+	value, err := s.Value()
+	assert.NoError(err)
+	assert.Equal(1.0, value)
+
+	// Outdated value is updated in background
+	s.interval = 0
+	_, err = s.Value()
+	assert.NoError(err)
+
+	assert.Eventually(func() bool {
+		value, _ := s.Value()
+		return value == 2
+	}, time.Second, time.Millisecond)
+
+	// Errors
+	require.NoError(os.WriteFile(valueFile, []byte("fake"), 0644))
+	assert.Eventually(func() bool {
+		_, err := s.Value()
+		return err != nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestSensorCommandErrorOnce(t *testing.T) {
+	assert := assert.New(t)
+
+	s := NewSensorCommand(config.Sensor{
+		Command:  []string{"false"},
+		Interval: models.SecondsPtr(1000),
+	})
+	assert.Error(s.Init())
+
+	_, err := s.Value()
+	assert.Error(err)
+	assert.NotErrorIs(err, ErrSensorUnchanged)
+
+	_, err = s.Value()
+	assert.ErrorIs(err, ErrSensorUnchanged)
+}
+
+func TestSensorCommandTimeout(t *testing.T) {
+	s := NewSensorCommand(config.Sensor{
+		Command: []string{"sleep", "10"},
+		Timeout: models.SecondsPtr(0.1),
+	})
+	assert.EqualError(t, s.Init(), "command timeout 100ms")
+}
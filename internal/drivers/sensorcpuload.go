@@ -0,0 +1,117 @@
+package drivers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
+
+// CPU utilization in percents between two Value calls
+type SensorCPULoad struct {
+	path       string
+	cores      bool
+	factor     float64
+	add        float64
+	selectFunc func([]float64) float64
+
+	previous map[string]cpuTimes
+}
+
+type cpuTimes struct {
+	idle  uint64
+	total uint64
+}
+
+func NewSensorCPULoad(conf config.Sensor) *SensorCPULoad {
+	inputs := newSensorInputs(conf, 1)
+
+	return &SensorCPULoad{
+		path:       cmp.Or(conf.Path, "/proc/stat"),
+		cores:      conf.Mode == models.CPULoadModeCores,
+		factor:     inputs.factor,
+		add:        inputs.add,
+		selectFunc: inputs.selectFunc,
+	}
+}
+
+// Reads the first sample
+func (s *SensorCPULoad) Init() error {
+	times, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	s.previous = times
+	return nil
+}
+
+func (s *SensorCPULoad) Value() (float64, error) {
+	times, err := s.read()
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]float64, 0, len(times))
+	for name, current := range times {
+		previous := s.previous[name]
+		total := float64(current.total) - float64(previous.total)
+		idle := float64(current.idle) - float64(previous.idle)
+		if total <= 0 {
+			values = append(values, 0)
+			continue
+		}
+
+		values = append(values, 100*max(0, min(1, (total-idle)/total)))
+	}
+
+	s.previous = times
+	return s.selectFunc(values)*s.factor + s.add, nil
+}
+
+// Reads cpu times of all cores or total cpu times
+func (s *SensorCPULoad) read() (map[string]cpuTimes, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[string]cpuTimes)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		if (fields[0] == "cpu") == s.cores {
+			continue
+		}
+
+		var cpu cpuTimes
+		// user nice system idle iowait irq softirq steal, guest time is included in user
+		for idx, field := range fields[1:min(len(fields), 9)] {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", fields[0], err)
+			}
+
+			cpu.total += value
+			if idx == 3 || idx == 4 {
+				cpu.idle += value
+			}
+		}
+
+		times[fields[0]] = cpu
+	}
+
+	if len(times) == 0 {
+		return nil, errors.New("cpu times not found")
+	}
+
+	return times, nil
+}
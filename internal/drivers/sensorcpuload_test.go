@@ -0,0 +1,72 @@
+package drivers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
+
+func TestSensorCPULoad(t *testing.T) {
+	require := require.New(t)
+
+	stat, err := os.CreateTemp("", "stat")
+	require.NoError(err)
+	defer os.Remove(stat.Name())
+
+	writeStat := func(content string) {
+		require.NoError(os.WriteFile(stat.Name(), []byte(content), 0644))
+	}
+
+	writeStat("cpu  100 0 100 700 100 0 0 0 0 0\n" +
+		"cpu0 50 0 50 350 50 0 0 0 0 0\n" +
+		"cpu1 50 0 50 350 50 0 0 0 0 0\n" +
+		"intr 12345 0 0\n" +
+		"ctxt 98765\n")
+
+	total := NewSensorCPULoad(config.Sensor{Path: stat.Name()})
+	require.NoError(total.Init())
+
+	cores := NewSensorCPULoad(config.Sensor{Path: stat.Name(), Mode: models.CPULoadModeCores})
+	require.NoError(cores.Init())
+
+	average := NewSensorCPULoad(config.Sensor{
+		Path:   stat.Name(),
+		Mode:   models.CPULoadModeCores,
+		Select: models.SelectFuncAverage,
+	})
+	require.NoError(average.Init())
+
+	// cpu0 is 80% busy, cpu1 is 60% busy
+	writeStat("cpu  200 0 200 800 100 0 0 0 0 0\n" +
+		"cpu0 130 0 50 370 50 0 0 0 0 0\n" +
+		"cpu1 70 0 150 430 50 0 0 0 0 0\n")
+
+	t.Run("total", func(t *testing.T) {
+		value, err := total.Value()
+		assert.NoError(t, err)
+		assert.InDelta(t, 66.67, value, 0.01)
+	})
+
+	t.Run("cores max", func(t *testing.T) {
+		value, err := cores.Value()
+		assert.NoError(t, err)
+		assert.InDelta(t, 80, value, 0.01)
+	})
+
+	t.Run("cores average", func(t *testing.T) {
+		value, err := average.Value()
+		assert.NoError(t, err)
+		assert.InDelta(t, 70, value, 0.01)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		value, err := total.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, value)
+	})
+}
@@ -0,0 +1,250 @@
+package drivers
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
+
+// Disk temperature from drivetemp or NVMe hwmon.
+// Disks in standby aren't read to not wake them up.
+type SensorDisk struct {
+	sensorInputs
+
+	path    string
+	serial  string
+	model   string
+	standby string
+	devPath string
+
+	// Checks ATA power mode of the disk device
+	ataStandby func(device string) (bool, error)
+
+	disks []diskInput
+}
+
+type diskInput struct {
+	name      string
+	blockDir  string
+	inputFile string
+	deviceDir string
+	ata       bool
+
+	last    float64
+	hasLast bool
+
+	rediscoverAt time.Time
+	backoff      time.Duration
+}
+
+// Hwmon directories of SATA drivetemp and NVMe controller
+var diskHwmonPatterns = []string{
+	"device/hwmon/hwmon*",
+	"device/hwmon*",
+	"device/device/hwmon/hwmon*",
+	"device/device/hwmon*",
+}
+
+func NewSensorDisk(conf config.Sensor) *SensorDisk {
+	return &SensorDisk{
+		sensorInputs: newSensorInputs(conf, 0.001),
+		path:         cmp.Or(conf.Path, "/sys/block"),
+		serial:       conf.Serial,
+		model:        conf.Model,
+		standby:      cmp.Or(conf.Standby, models.DiskStandbyLast),
+		devPath:      "/dev",
+		ataStandby:   ataStandby,
+	}
+}
+
+// Finds disks by serial and model which have temperature input
+func (s *SensorDisk) Init() error {
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		blockDir := path.Join(s.path, entry.Name())
+		deviceDir := path.Join(blockDir, "device")
+		if _, err := os.Stat(deviceDir); err != nil {
+			continue
+		}
+
+		if s.serial != "" && !matchName(s.serial, diskSerial(deviceDir)) {
+			continue
+		}
+
+		if s.model != "" {
+			model, _ := ReadSysFile(path.Join(deviceDir, "model"))
+			if !matchName(s.model, model) {
+				continue
+			}
+		}
+
+		inputFile := diskInputFile(blockDir)
+		if inputFile == "" {
+			continue
+		}
+
+		vendor, _ := ReadSysFile(path.Join(deviceDir, "vendor"))
+
+		s.disks = append(s.disks, diskInput{
+			name:      entry.Name(),
+			blockDir:  blockDir,
+			inputFile: inputFile,
+			deviceDir: deviceDir,
+			ata:       vendor == "ATA",
+		})
+	}
+
+	if len(s.disks) == 0 {
+		return errors.New("disk not found")
+	}
+
+	return nil
+}
+
+// Returns the last value of disks in standby or skips them according to
+// standby mode. Returns ErrSensorIdle if all disks are skipped.
+func (s *SensorDisk) Value() (float64, error) {
+	values := make([]float64, 0, len(s.disks))
+	rejected := false
+
+	for i := range s.disks {
+		disk := &s.disks[i]
+
+		if s.isStandby(disk) {
+			if s.standby == models.DiskStandbyLast && disk.hasLast {
+				values = append(values, disk.last)
+			}
+
+			continue
+		}
+
+		value, err := s.readDisk(disk)
+		if err != nil {
+			return 0, fmt.Errorf("disk %s: %w", disk.name, err)
+		}
+
+		if !s.guard.Accept(disk.inputFile, value) {
+			rejected = true
+			continue
+		}
+
+		disk.last = value
+		disk.hasLast = true
+		values = append(values, value)
+	}
+
+	if len(values) == 0 && rejected {
+		return 0, errRejected
+	}
+
+	if len(values) == 0 {
+		return 0, ErrSensorIdle
+	}
+
+	return s.selectFunc(values), nil
+}
+
+// Returns true if the disk is runtime suspended or ATA disk is spun down
+func (s *SensorDisk) isStandby(disk *diskInput) bool {
+	if diskStandby(disk.deviceDir) {
+		return true
+	}
+
+	if !disk.ata {
+		return false
+	}
+
+	standby, err := s.ataStandby(path.Join(s.devPath, disk.name))
+	if err != nil {
+		slog.Debug("failed to check disk power mode", "disk", disk.name, "error", err)
+		return false
+	}
+
+	return standby
+}
+
+// Reads disk temperature. Finds the input again if it disappeared,
+// e.g. hwmon number changed after drivetemp or nvme module reload.
+// Attempts are repeated with exponential backoff.
+func (s *SensorDisk) readDisk(disk *diskInput) (float64, error) {
+	value, err := s.read(disk.inputFile)
+	if err == nil || !isDeviceGone(err) {
+		return value, err
+	}
+
+	if time.Now().Before(disk.rediscoverAt) {
+		return 0, err
+	}
+
+	inputFile := diskInputFile(disk.blockDir)
+	if inputFile == "" {
+		disk.backoff = min(max(2*disk.backoff, hwmonMinBackoff), hwmonMaxBackoff)
+		disk.rediscoverAt = time.Now().Add(disk.backoff)
+		slog.Debug("disk input rediscovery failed", "disk", disk.name, "retry", disk.backoff)
+		return 0, err
+	}
+
+	slog.Info("disk input rediscovered", "disk", disk.name, "file", inputFile)
+	disk.inputFile = inputFile
+	disk.backoff = 0
+	disk.rediscoverAt = time.Time{}
+	return s.read(disk.inputFile)
+}
+
+// Returns serial number of NVMe or SCSI disk. SCSI serial is taken from
+// unit serial number VPD page.
+func diskSerial(deviceDir string) string {
+	if serial, err := ReadSysFile(path.Join(deviceDir, "serial")); err == nil {
+		return serial
+	}
+
+	page, err := os.ReadFile(path.Join(deviceDir, "vpd_pg80"))
+	if err != nil || len(page) < 4 {
+		return ""
+	}
+
+	return string(bytes.TrimSpace(page[4:]))
+}
+
+// Returns the first temperature input of the disk hwmon
+func diskInputFile(blockDir string) string {
+	for _, pattern := range diskHwmonPatterns {
+		dirs, _ := filepath.Glob(path.Join(blockDir, pattern))
+		for _, dir := range dirs {
+			inputFile := path.Join(dir, "temp1_input")
+			if _, err := os.Stat(inputFile); err == nil {
+				return inputFile
+			}
+		}
+	}
+
+	return ""
+}
+
+// Returns true if the disk isn't running or runtime suspended.
+// ATA STANDBY, e.g. after hdparm -y or the disk spindown timer, isn't
+// visible in sysfs, it's checked with ataStandby.
+func diskStandby(deviceDir string) bool {
+	if state, err := ReadSysFile(path.Join(deviceDir, "state")); err == nil {
+		if !slices.Contains([]string{"running", "live"}, state) {
+			return true
+		}
+	}
+
+	status, err := ReadSysFile(path.Join(deviceDir, "power/runtime_status"))
+	return err == nil && status == "suspended"
+}
@@ -0,0 +1,193 @@
+package drivers
+
+import (
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
+
+func TestSensorDisk(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "block")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"sda/device/model":                               "WDC WD40EFRX-68N",
+		"sda/device/vendor":                              "ATA",
+		"sda/device/vpd_pg80":                            "\x00\x80\x00\x14     WD-WCC7K1234567",
+		"sda/device/state":                               "running",
+		"sda/device/power/runtime_status":                "active",
+		"sda/device/hwmon/hwmon3/temp1_input":            "38000",
+		"sdb/device/model":                               "WDC WD40EFRX-68N",
+		"sdb/device/vendor":                              "ATA",
+		"sdb/device/vpd_pg80":                            "\x00\x80\x00\x14     WD-WCC7K7654321",
+		"sdb/device/state":                               "running",
+		"sdb/device/power/runtime_status":                "active",
+		"sdb/device/hwmon/hwmon4/temp1_input":            "41000",
+		"nvme0n1/device/serial":                          "S4EWNX0R123456",
+		"nvme0n1/device/model":                           "Samsung SSD 970 EVO Plus 1TB",
+		"nvme0n1/device/state":                           "live",
+		"nvme0n1/device/device/hwmon/hwmon1/temp1_input": "45850",
+		"loop0/size":                                     "0",
+	})
+
+	spunDown := map[string]bool{}
+	newDisk := func(conf config.Sensor) *SensorDisk {
+		s := NewSensorDisk(conf)
+		s.ataStandby = func(device string) (bool, error) {
+			if path.Base(device) == "nvme0n1" {
+				return false, errors.New("not ATA disk")
+			}
+
+			return spunDown[path.Base(device)], nil
+		}
+
+		return s
+	}
+
+	setStandby := func(disk string, standby bool) {
+		status := "active"
+		if standby {
+			status = "suspended"
+		}
+
+		err := os.WriteFile(path.Join(tmpDir, disk, "device/power/runtime_status"), []byte(status), 0644)
+		require.NoError(t, err)
+	}
+
+	t.Run("model", func(t *testing.T) {
+		assert := assert.New(t)
+
+		s := newDisk(config.Sensor{Path: tmpDir, Model: "WD40EFRX"})
+		require.NoError(t, s.Init())
+		assert.Len(s.disks, 2)
+
+		value, err := s.Value()
+		assert.NoError(err)
+		assert.Equal(41.0, value)
+	})
+
+	t.Run("serial", func(t *testing.T) {
+		assert := assert.New(t)
+
+		s := newDisk(config.Sensor{Path: tmpDir, Serial: "S4EWNX0R123456"})
+		require.NoError(t, s.Init())
+
+		value, err := s.Value()
+		assert.NoError(err)
+		assert.Equal(45.85, value)
+
+		s = newDisk(config.Sensor{Path: tmpDir, Serial: "WD-WCC7K1234567"})
+		require.NoError(t, s.Init())
+
+		value, err = s.Value()
+		assert.NoError(err)
+		assert.Equal(38.0, value)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s := newDisk(config.Sensor{Path: tmpDir, Serial: "fake"})
+		assert.EqualError(t, s.Init(), "disk not found")
+	})
+
+	t.Run("standby last", func(t *testing.T) {
+		assert := assert.New(t)
+		defer setStandby("sdb", false)
+
+		s := newDisk(config.Sensor{Path: tmpDir, Model: "WD40EFRX", Select: models.SelectFuncMin})
+		require.NoError(t, s.Init())
+
+		setStandby("sdb", true)
+		value, err := s.Value()
+		assert.NoError(err)
+		assert.Equal(38.0, value)
+
+		setStandby("sdb", false)
+		value, err = s.Value()
+		assert.NoError(err)
+		assert.Equal(38.0, value)
+
+		setStandby("sda", true)
+		defer setStandby("sda", false)
+		require.NoError(t, os.WriteFile(path.Join(tmpDir, "sda/device/hwmon/hwmon3/temp1_input"), []byte("20000"), 0644))
+		defer os.WriteFile(path.Join(tmpDir, "sda/device/hwmon/hwmon3/temp1_input"), []byte("38000"), 0644)
+
+		value, err = s.Value()
+		assert.NoError(err)
+		assert.Equal(38.0, value)
+	})
+
+	// Disk spun down by hdparm -y or its timer looks running in sysfs
+	t.Run("ata standby", func(t *testing.T) {
+		assert := assert.New(t)
+		defer delete(spunDown, "sda")
+
+		s := newDisk(config.Sensor{Path: tmpDir, Serial: "WD-WCC7K1234567", Standby: models.DiskStandbySkip})
+		require.NoError(t, s.Init())
+		assert.True(s.disks[0].ata)
+
+		value, err := s.Value()
+		assert.NoError(err)
+		assert.Equal(38.0, value)
+
+		spunDown["sda"] = true
+		_, err = s.Value()
+		assert.ErrorIs(err, ErrSensorIdle)
+
+		s = newDisk(config.Sensor{Path: tmpDir})
+		require.NoError(t, s.Init())
+
+		value, err = s.Value()
+		assert.NoError(err)
+		assert.Equal(45.85, value)
+	})
+
+	t.Run("rediscovery", func(t *testing.T) {
+		assert := assert.New(t)
+
+		s := newDisk(config.Sensor{Path: tmpDir, Serial: "WD-WCC7K7654321"})
+		require.NoError(t, s.Init())
+
+		hwmonDir := path.Join(tmpDir, "sdb/device/hwmon")
+		require.NoError(t, os.Rename(path.Join(hwmonDir, "hwmon4"), path.Join(hwmonDir, "hwmon7")))
+		defer os.Rename(path.Join(hwmonDir, "hwmon7"), path.Join(hwmonDir, "hwmon4"))
+
+		value, err := s.Value()
+		assert.NoError(err)
+		assert.Equal(41.0, value)
+		assert.Equal(path.Join(hwmonDir, "hwmon7/temp1_input"), s.disks[0].inputFile)
+
+		removedDir := t.TempDir()
+		require.NoError(t, os.Rename(path.Join(hwmonDir, "hwmon7"), path.Join(removedDir, "hwmon7")))
+		defer os.Rename(path.Join(removedDir, "hwmon7"), path.Join(hwmonDir, "hwmon7"))
+
+		_, err = s.Value()
+		assert.ErrorIs(err, os.ErrNotExist)
+		assert.NotZero(s.disks[0].backoff)
+	})
+
+	t.Run("standby skip", func(t *testing.T) {
+		assert := assert.New(t)
+		defer setStandby("sda", false)
+		defer setStandby("sdb", false)
+
+		s := newDisk(config.Sensor{Path: tmpDir, Model: "WD40EFRX", Standby: models.DiskStandbySkip})
+		require.NoError(t, s.Init())
+
+		setStandby("sdb", true)
+		value, err := s.Value()
+		assert.NoError(err)
+		assert.Equal(38.0, value)
+
+		setStandby("sda", true)
+		_, err = s.Value()
+		assert.ErrorIs(err, ErrSensorIdle)
+	})
+}
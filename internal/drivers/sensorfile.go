@@ -0,0 +1,37 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+// Reads numeric values from any files, path can be a shell pattern
+type SensorFile struct {
+	sensorInputs
+
+	path string
+}
+
+func NewSensorFile(conf config.Sensor) *SensorFile {
+	return &SensorFile{
+		sensorInputs: newSensorInputs(conf, 1),
+		path:         conf.Path,
+	}
+}
+
+func (s *SensorFile) Init() error {
+	files, err := filepath.Glob(s.path)
+	if err != nil {
+		return fmt.Errorf("path pattern: %w", err)
+	}
+
+	if len(files) == 0 {
+		return errors.New("files not found: " + s.path)
+	}
+
+	s.files = files
+	return nil
+}
@@ -0,0 +1,51 @@
+package drivers
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+	"github.com/IvanSafonov/fanctl/internal/utils"
+)
+
+func TestSensorFile(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "power_supply")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"BAT0/temp": "312\n",
+		"BAT1/temp": "298",
+		"AC/online": "1",
+	})
+
+	s := NewSensorFile(config.Sensor{
+		Path:   path.Join(tmpDir, "BAT0/temp"),
+		Factor: utils.Ptr(0.1),
+	})
+	assert.NoError(s.Init())
+
+	value, err := s.Value()
+	assert.NoError(err)
+	assert.InDelta(31.2, value, 0.0001)
+
+	s = NewSensorFile(config.Sensor{
+		Path:   path.Join(tmpDir, "BAT*/temp"),
+		Select: models.SelectFuncAverage,
+	})
+	assert.NoError(s.Init())
+
+	value, err = s.Value()
+	assert.NoError(err)
+	assert.Equal(305.0, value)
+
+	s = NewSensorFile(config.Sensor{Path: path.Join(tmpDir, "BAT2/temp")})
+	assert.Error(s.Init())
+}
@@ -4,123 +4,207 @@ import (
 	"cmp"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path"
-	"strconv"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/IvanSafonov/fanctl/internal/config"
 	"github.com/IvanSafonov/fanctl/internal/models"
 )
 
 type SensorHwmon struct {
+	sensorInputs
+
 	path       string
-	sensor     string
+	filter     hwmonFilter
 	label      string
-	factor     float64
-	add        float64
-	inputFiles []string
-	selectFunc func([]float64) float64
+	labelRegex *regexp.Regexp
+	input      string
+	class      string
+
+	rediscoverAt time.Time
+	backoff      time.Duration
+}
+
+const (
+	hwmonMinBackoff = time.Second
+	hwmonMaxBackoff = time.Minute
+)
+
+// Default factors convert hwmon units to degrees, RPM, watts, volts and amperes
+var hwmonClassFactors = map[string]float64{
+	models.HwmonClassTemp:  0.001,
+	models.HwmonClassFan:   1,
+	models.HwmonClassPower: 0.000001,
+	models.HwmonClassIn:    0.001,
+	models.HwmonClassCurr:  0.001,
 }
 
 func NewSensorHwmon(conf config.Sensor) *SensorHwmon {
-	factor := 0.001
-	if conf.Factor != nil {
-		factor = *conf.Factor
+	filter := hwmonFilter{
+		name:   conf.Sensor,
+		device: conf.Device,
+	}
+
+	if conf.SensorRegex != "" {
+		filter.nameRegex = regexp.MustCompile(conf.SensorRegex)
+	}
+
+	if filter.name == "" && filter.nameRegex == nil && filter.device == "" {
+		filter.name = "coretemp"
+	}
+
+	var labelRegex *regexp.Regexp
+	if conf.LabelRegex != "" {
+		labelRegex = regexp.MustCompile(conf.LabelRegex)
+	}
+
+	// Class of explicit input by default, e.g. fan for fan2
+	class := conf.Class
+	if match := models.HwmonInputRegex.FindStringSubmatch(conf.Input); class == "" && match != nil {
+		class = match[1]
 	}
 
-	add := 0.0
-	if conf.Add != nil {
-		add = *conf.Add
+	class = cmp.Or(class, models.HwmonClassTemp)
+	factor, ok := hwmonClassFactors[class]
+	if !ok {
+		factor = 1
 	}
 
 	return &SensorHwmon{
-		path:       cmp.Or(conf.Path, "/sys/class/hwmon"),
-		sensor:     cmp.Or(conf.Sensor, "coretemp"),
-		label:      conf.Label,
-		factor:     factor,
-		add:        add,
-		selectFunc: models.SelectFunc(conf.Select),
+		sensorInputs: newSensorInputs(conf, factor),
+		path:         cmp.Or(conf.Path, "/sys/class/hwmon"),
+		filter:       filter,
+		label:        conf.Label,
+		labelRegex:   labelRegex,
+		input:        conf.Input,
+		class:        class,
 	}
 }
 
 func (s *SensorHwmon) Init() error {
-	sensorsDirs, err := os.ReadDir(s.path)
+	files, err := s.discover()
 	if err != nil {
-		return fmt.Errorf("read dir: %w", err)
+		return err
 	}
 
-	for _, entry := range sensorsDirs {
-		sensorDir := path.Join(s.path, entry.Name())
-		sensorNameFile := path.Join(sensorDir, "name")
-		if _, err := os.Stat(sensorNameFile); os.IsNotExist(err) {
-			continue
-		}
+	s.files = files
+	return nil
+}
 
-		sensorName, err := ReadSysFile(sensorNameFile)
-		if err != nil {
-			return fmt.Errorf("read sensor name: %w", err)
-		}
+// Reads input files. Discovers them again if they disappeared,
+// e.g. hwmon numbers changed after resume or module reload.
+// Attempts are repeated with exponential backoff.
+func (s *SensorHwmon) Value() (float64, error) {
+	value, err := s.sensorInputs.Value()
+	if err == nil || !isDeviceGone(err) {
+		return value, err
+	}
 
-		if !strings.Contains(string(sensorName), s.sensor) {
-			continue
-		}
+	if time.Now().Before(s.rediscoverAt) {
+		return 0, err
+	}
+
+	files, discoverErr := s.discover()
+	if discoverErr != nil {
+		s.backoff = min(max(2*s.backoff, hwmonMinBackoff), hwmonMaxBackoff)
+		s.rediscoverAt = time.Now().Add(s.backoff)
+		slog.Debug("hwmon rediscovery failed", "error", discoverErr, "retry", s.backoff)
+		return 0, err
+	}
+
+	slog.Info("hwmon inputs rediscovered", "files", files)
+	s.files = files
+	s.backoff = 0
+	s.rediscoverAt = time.Time{}
+	return s.sensorInputs.Value()
+}
+
+// Finds input files of the class in matching hwmon directories.
+// Inputs are selected by label or by explicit name like temp3.
+// Power inputs without _input file use _average file.
+func (s *SensorHwmon) discover() ([]string, error) {
+	sensorDirs, err := findHwmonDirs(s.path, s.filter)
+	if err != nil {
+		return nil, err
+	}
 
+	var files []string
+
+	for _, sensorDir := range sensorDirs {
 		sensorFiles, err := os.ReadDir(sensorDir)
 		if err != nil {
-			return fmt.Errorf("read dir: %w", err)
+			return nil, fmt.Errorf("read dir: %w", err)
 		}
 
 		for _, sensorFileInfo := range sensorFiles {
-			if sensorFileInfo.IsDir() || !strings.HasSuffix(sensorFileInfo.Name(), "_label") {
+			inputName, ok := s.inputName(sensorDir, sensorFileInfo.Name())
+			if sensorFileInfo.IsDir() || !ok {
 				continue
 			}
 
-			if s.label != "" {
-				label, err := ReadSysFile(path.Join(sensorDir, sensorFileInfo.Name()))
-				if err != nil {
-					return fmt.Errorf("read label: %w", err)
-				}
-
-				if !strings.Contains(string(label), s.label) {
-					continue
-				}
+			if match := models.HwmonInputRegex.FindStringSubmatch(inputName); match == nil || match[1] != s.class {
+				continue
 			}
 
-			inputFile := path.Join(sensorDir, strings.ReplaceAll(sensorFileInfo.Name(), "_label", "_input"))
-			if _, err := os.Stat(inputFile); os.IsNotExist(err) {
+			if s.input != "" && inputName != s.input {
 				continue
 			}
 
-			s.inputFiles = append(s.inputFiles, inputFile)
+			matched, err := s.matchLabel(path.Join(sensorDir, inputName+"_label"))
+			if err != nil {
+				return nil, err
+			}
+
+			if matched {
+				files = append(files, path.Join(sensorDir, sensorFileInfo.Name()))
+			}
 		}
 	}
 
-	if len(s.inputFiles) == 0 {
-		return errors.New("input files not found")
+	if len(files) == 0 {
+		return nil, errors.New("input files not found")
 	}
 
-	return nil
+	return files, nil
 }
 
-func (s *SensorHwmon) Value() (float64, error) {
-	values := make([]float64, 0, len(s.inputFiles))
+// Returns input name like temp1 if the file is input value file
+func (s *SensorHwmon) inputName(dir, fileName string) (string, bool) {
+	if name, ok := strings.CutSuffix(fileName, "_input"); ok {
+		return name, true
+	}
 
-	for _, inputFile := range s.inputFiles {
-		data, err := ReadSysFile(inputFile)
-		if err != nil {
-			return 0, fmt.Errorf("read input: %w", err)
-		}
+	name, ok := strings.CutSuffix(fileName, "_average")
+	if !ok || s.class != models.HwmonClassPower {
+		return "", false
+	}
 
-		value, err := strconv.ParseFloat(data, 64)
-		if err != nil {
-			return 0, fmt.Errorf("parse input: %w", err)
-		}
+	_, err := os.Stat(path.Join(dir, name+"_input"))
+	return name, os.IsNotExist(err)
+}
+
+// Inputs without label file match only when there is no label filter
+func (s *SensorHwmon) matchLabel(labelFile string) (bool, error) {
+	if _, err := os.Stat(labelFile); os.IsNotExist(err) {
+		return s.label == "" && s.labelRegex == nil, nil
+	}
+
+	if s.label == "" && s.labelRegex == nil {
+		return true, nil
+	}
+
+	label, err := ReadSysFile(labelFile)
+	if err != nil {
+		return false, fmt.Errorf("read label: %w", err)
+	}
 
-		value = value*s.factor + s.add
-		values = append(values, value)
+	if !strings.Contains(label, s.label) {
+		return false, nil
 	}
 
-	result := s.selectFunc(values)
-	return result, nil
+	return s.labelRegex == nil || s.labelRegex.MatchString(label), nil
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -43,6 +44,51 @@ func TestSensorHwmon(t *testing.T) {
 	assert.Equal(34.85, value)
 }
 
+func TestSensorHwmonRediscover(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "hwmon")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"hwmon6/name":        "coretemp",
+		"hwmon6/temp1_label": "Package id 0",
+		"hwmon6/temp1_input": "30200",
+	})
+
+	s := NewSensorHwmon(config.Sensor{Path: tmpDir})
+	require.NoError(t, s.Init())
+
+	require.NoError(t, os.Rename(path.Join(tmpDir, "hwmon6"), path.Join(tmpDir, "hwmon7")))
+
+	value, err := s.Value()
+	assert.NoError(err)
+	assert.Equal(30.2, value)
+	assert.Equal([]string{path.Join(tmpDir, "hwmon7/temp1_input")}, s.files)
+
+	removedDir, err := os.MkdirTemp("", "removed")
+	require.NoError(t, err)
+	defer os.RemoveAll(removedDir)
+
+	require.NoError(t, os.Rename(path.Join(tmpDir, "hwmon7"), path.Join(removedDir, "hwmon7")))
+
+	_, err = s.Value()
+	assert.ErrorIs(err, os.ErrNotExist)
+	assert.Equal(hwmonMinBackoff, s.backoff)
+
+	require.NoError(t, os.Rename(path.Join(removedDir, "hwmon7"), path.Join(tmpDir, "hwmon8")))
+
+	_, err = s.Value()
+	assert.ErrorIs(err, os.ErrNotExist)
+
+	s.rediscoverAt = time.Now()
+	value, err = s.Value()
+	assert.NoError(err)
+	assert.Equal(30.2, value)
+	assert.Zero(s.backoff)
+}
+
 func createFiles(t *testing.T, root string, files map[string]string) {
 	for name, content := range files {
 		dir, fileName := path.Split(name)
@@ -56,5 +102,157 @@ func createFiles(t *testing.T, root string, files map[string]string) {
 
 		_, err = file.WriteString(content)
 		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+}
+
+func TestSensorHwmonMatch(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "hwmon")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"devices/0000:01:00.0/vendor": "0x144d",
+		"devices/0000:02:00.0/vendor": "0x15b7",
+		"devices/0000:00:18.3/vendor": "0x1022",
+
+		"class/hwmon1/name":        "nvme",
+		"class/hwmon1/temp1_label": "Composite",
+		"class/hwmon1/temp1_input": "41850",
+		"class/hwmon1/temp2_label": "Sensor 1",
+		"class/hwmon1/temp2_input": "52850",
+
+		"class/hwmon2/name":        "nvme",
+		"class/hwmon2/temp1_label": "Composite",
+		"class/hwmon2/temp1_input": "37850",
+
+		"class/hwmon3/name":        "k10temp",
+		"class/hwmon3/temp1_input": "55000",
+		"class/hwmon3/temp3_input": "48000",
+
+		"class/hwmon4/name":        "nct6798",
+		"class/hwmon4/temp1_input": "30000",
+		"class/hwmon4/fan1_input":  "0",
+		"class/hwmon4/fan2_input":  "1250",
+		"class/hwmon4/in0_input":   "1104",
+		"class/hwmon4/curr1_input": "2500",
+
+		"class/hwmon5/name":           "amdgpu",
+		"class/hwmon5/power1_average": "35000000",
+		"class/hwmon5/power2_average": "15000000",
+		"class/hwmon5/power2_input":   "17000000",
+		"class/hwmon5/power1_cap":     "150000000",
+		"class/hwmon5/temp1_input":    "45000",
+		"class/hwmon5/fan1_input":     "800",
+		"class/hwmon5/fan1_label":     "gpu",
+		"class/hwmon5/in0_label":      "vddgfx",
+		"class/hwmon5/in0_input":      "750",
+	})
+
+	for hwmon, device := range map[string]string{
+		"hwmon1": "0000:01:00.0",
+		"hwmon2": "0000:02:00.0",
+		"hwmon3": "0000:00:18.3",
+	} {
+		err := os.Symlink(path.Join(tmpDir, "devices", device), path.Join(tmpDir, "class", hwmon, "device"))
+		require.NoError(t, err)
+	}
+
+	cases := []struct {
+		name  string
+		conf  config.Sensor
+		value float64
+		err   string
+	}{
+		{
+			name:  "all nvme",
+			conf:  config.Sensor{Sensor: "nvme"},
+			value: 52.85,
+		},
+		{
+			name:  "nvme by device",
+			conf:  config.Sensor{Device: "0000:02:00.0"},
+			value: 37.85,
+		},
+		{
+			name:  "nvme by device and label regex",
+			conf:  config.Sensor{Device: "0000:01:00.0", LabelRegex: "^Comp"},
+			value: 41.85,
+		},
+		{
+			name:  "input without label",
+			conf:  config.Sensor{Sensor: "k10temp", Input: "temp3"},
+			value: 48,
+		},
+		{
+			name:  "sensor regex and input",
+			conf:  config.Sensor{SensorRegex: "^nct67", Input: "temp1"},
+			value: 30,
+		},
+		{
+			name:  "inputs without labels",
+			conf:  config.Sensor{Sensor: "k10temp"},
+			value: 55,
+		},
+		{
+			name:  "fan class",
+			conf:  config.Sensor{Sensor: "nct6798", Class: models.HwmonClassFan},
+			value: 1250,
+		},
+		{
+			name:  "class from input",
+			conf:  config.Sensor{Sensor: "nct6798", Input: "fan1"},
+			value: 0,
+		},
+		{
+			name:  "voltage",
+			conf:  config.Sensor{Sensor: "nct6798", Class: models.HwmonClassIn},
+			value: 1.104,
+		},
+		{
+			name:  "current",
+			conf:  config.Sensor{Sensor: "nct6798", Class: models.HwmonClassCurr},
+			value: 2.5,
+		},
+		{
+			name:  "power average",
+			conf:  config.Sensor{Sensor: "amdgpu", Class: models.HwmonClassPower, Select: models.SelectFuncMin},
+			value: 17,
+		},
+		{
+			name:  "power average without input",
+			conf:  config.Sensor{Sensor: "amdgpu", Input: "power1"},
+			value: 35,
+		},
+		{
+			name:  "labelled fan",
+			conf:  config.Sensor{Sensor: "amdgpu", Class: models.HwmonClassFan, Label: "gpu"},
+			value: 800,
+		},
+		{
+			name: "label filter without label",
+			conf: config.Sensor{Sensor: "k10temp", Input: "temp3", Label: "Tctl"},
+			err:  "input files not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			tc.conf.Path = path.Join(tmpDir, "class")
+			s := NewSensorHwmon(tc.conf)
+
+			err := s.Init()
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			value, err := s.Value()
+			assert.NoError(err)
+			assert.InDelta(tc.value, value, 0.0001)
+		})
 	}
 }
@@ -0,0 +1,77 @@
+package drivers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
+
+// Battery temperature, charge or AC adapter state from power supply class
+type SensorPowerSupply struct {
+	sensorInputs
+
+	path   string
+	sensor string
+	mode   string
+}
+
+func NewSensorPowerSupply(conf config.Sensor) *SensorPowerSupply {
+	mode := cmp.Or(conf.Mode, models.PowerSupplyModeCapacity)
+
+	// Temperature is in tenths of degree
+	factor := 1.0
+	if mode == models.PowerSupplyModeTemp {
+		factor = 0.1
+	}
+
+	return &SensorPowerSupply{
+		sensorInputs: newSensorInputs(conf, factor),
+		path:         cmp.Or(conf.Path, "/sys/class/power_supply"),
+		sensor:       conf.Sensor,
+		mode:         mode,
+	}
+}
+
+// Finds power supplies by name which have the mode file.
+// Skips peripheral devices like wireless mice, temperature and capacity are read only from batteries.
+func (s *SensorPowerSupply) Init() error {
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !matchName(s.sensor, entry.Name()) {
+			continue
+		}
+
+		supplyDir := path.Join(s.path, entry.Name())
+		if scope, _ := ReadSysFile(path.Join(supplyDir, "scope")); scope == "Device" {
+			continue
+		}
+
+		if s.mode != models.PowerSupplyModeOnline {
+			if supplyType, _ := ReadSysFile(path.Join(supplyDir, "type")); supplyType != "Battery" {
+				continue
+			}
+		}
+
+		inputFile := path.Join(supplyDir, s.mode)
+		if _, err := os.Stat(inputFile); err != nil {
+			continue
+		}
+
+		s.files = append(s.files, inputFile)
+	}
+
+	if len(s.files) == 0 {
+		return errors.New("power supply not found: " + s.sensor)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package drivers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
+
+func TestSensorPowerSupply(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "power_supply")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"AC/type":            "Mains",
+		"AC/online":          "1",
+		"BAT0/type":          "Battery",
+		"BAT0/capacity":      "85",
+		"BAT0/temp":          "312",
+		"BAT1/type":          "Battery",
+		"BAT1/capacity":      "40",
+		"hid-mouse/type":     "Battery",
+		"hid-mouse/scope":    "Device",
+		"hid-mouse/capacity": "5",
+		"ups/type":           "UPS",
+		"ups/capacity":       "10",
+	})
+
+	cases := []struct {
+		conf  config.Sensor
+		value float64
+	}{
+		{conf: config.Sensor{Sensor: "BAT*", Select: models.SelectFuncMin}, value: 40},
+		{conf: config.Sensor{Mode: models.PowerSupplyModeCapacity, Select: models.SelectFuncMin}, value: 40},
+		{conf: config.Sensor{Mode: models.PowerSupplyModeTemp}, value: 31.2},
+		{conf: config.Sensor{Mode: models.PowerSupplyModeOnline}, value: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.conf.Mode, func(t *testing.T) {
+			tc.conf.Path = tmpDir
+			s := NewSensorPowerSupply(tc.conf)
+			require.NoError(t, s.Init())
+
+			value, err := s.Value()
+			assert.NoError(t, err)
+			assert.InDelta(t, tc.value, value, 0.0001)
+		})
+	}
+
+	s := NewSensorPowerSupply(config.Sensor{Path: tmpDir, Sensor: "BAT2"})
+	assert.Error(t, s.Init())
+}
@@ -0,0 +1,143 @@
+package drivers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+// Power in watts calculated from powercap energy counters between two Value calls
+type SensorRAPL struct {
+	path       string
+	sensor     string
+	factor     float64
+	add        float64
+	selectFunc func([]float64) float64
+
+	zones []raplZone
+}
+
+type raplZone struct {
+	energyFile string
+	maxEnergy  uint64
+	energy     uint64
+	energyTime time.Time
+}
+
+func NewSensorRAPL(conf config.Sensor) *SensorRAPL {
+	inputs := newSensorInputs(conf, 1)
+
+	return &SensorRAPL{
+		path:       cmp.Or(conf.Path, "/sys/class/powercap"),
+		sensor:     cmp.Or(conf.Sensor, "package-*"),
+		factor:     inputs.factor,
+		add:        inputs.add,
+		selectFunc: inputs.selectFunc,
+	}
+}
+
+// Finds powercap zones by name and reads the first energy values
+func (s *SensorRAPL) Init() error {
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "intel-rapl:") {
+			continue
+		}
+
+		zoneDir := path.Join(s.path, entry.Name())
+		name, err := ReadSysFile(path.Join(zoneDir, "name"))
+		if err != nil {
+			return fmt.Errorf("read name: %w", err)
+		}
+
+		if !matchName(s.sensor, name) {
+			continue
+		}
+
+		maxEnergy, err := readUint(path.Join(zoneDir, "max_energy_range_uj"))
+		if err != nil {
+			return fmt.Errorf("read max energy: %w", err)
+		}
+
+		zone := raplZone{
+			energyFile: path.Join(zoneDir, "energy_uj"),
+			maxEnergy:  maxEnergy,
+		}
+
+		if _, err := zone.power(); err != nil {
+			return err
+		}
+
+		s.zones = append(s.zones, zone)
+	}
+
+	if len(s.zones) == 0 {
+		return errors.New("powercap zone not found: " + s.sensor)
+	}
+
+	return nil
+}
+
+func (s *SensorRAPL) Value() (float64, error) {
+	values := make([]float64, 0, len(s.zones))
+
+	for i := range s.zones {
+		power, err := s.zones[i].power()
+		if err != nil {
+			return 0, err
+		}
+
+		values = append(values, power*s.factor+s.add)
+	}
+
+	return s.selectFunc(values), nil
+}
+
+// Returns average power in watts since the previous call
+func (z *raplZone) power() (float64, error) {
+	energy, err := readUint(z.energyFile)
+	if err != nil {
+		return 0, fmt.Errorf("read energy: %w", err)
+	}
+
+	now := time.Now()
+	previous, previousTime := z.energy, z.energyTime
+	z.energy, z.energyTime = energy, now
+
+	if previousTime.IsZero() {
+		return 0, nil
+	}
+
+	// The counter starts from zero after max energy
+	delta := energy - previous
+	if energy < previous {
+		delta = z.maxEnergy - previous + energy
+	}
+
+	seconds := now.Sub(previousTime).Seconds()
+	if seconds <= 0 {
+		return 0, nil
+	}
+
+	return float64(delta) / 1e6 / seconds, nil
+}
+
+func readUint(name string) (uint64, error) {
+	data, err := ReadSysFile(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(data, 10, 64)
+}
@@ -0,0 +1,58 @@
+package drivers
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+func TestSensorRAPL(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "powercap")
+	require.NoError(err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"intel-rapl/enabled":                    "1",
+		"intel-rapl:0/name":                     "package-0",
+		"intel-rapl:0/energy_uj":                "1000000",
+		"intel-rapl:0/max_energy_range_uj":      "262143328850",
+		"intel-rapl:0:0/name":                   "core",
+		"intel-rapl:0:0/energy_uj":              "500000",
+		"intel-rapl:0:0/max_energy_range_uj":    "262143328850",
+		"intel-rapl-mmio:0/name":                "package-0",
+		"intel-rapl-mmio:0/energy_uj":           "0",
+		"intel-rapl-mmio:0/max_energy_range_uj": "262143328850",
+	})
+
+	s := NewSensorRAPL(config.Sensor{Path: tmpDir})
+	require.NoError(s.Init())
+	require.Len(s.zones, 1)
+
+	energyFile := path.Join(tmpDir, "intel-rapl:0/energy_uj")
+
+	// 15 joules in 2 seconds
+	s.zones[0].energyTime = time.Now().Add(-2 * time.Second)
+	require.NoError(os.WriteFile(energyFile, []byte("16000000"), 0644))
+
+	value, err := s.Value()
+	assert.NoError(err)
+	assert.InDelta(7.5, value, 0.01)
+
+	// Counter wraparound, 10 joules in 2 seconds
+	s.zones[0].energyTime = time.Now().Add(-2 * time.Second)
+	s.zones[0].energy = 262143328850 - 4000000
+	require.NoError(os.WriteFile(energyFile, []byte("6000000"), 0644))
+
+	value, err = s.Value()
+	assert.NoError(err)
+	assert.InDelta(5, value, 0.01)
+}
@@ -0,0 +1,59 @@
+package drivers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+)
+
+type SensorThermal struct {
+	sensorInputs
+
+	path   string
+	sensor string
+}
+
+func NewSensorThermal(conf config.Sensor) *SensorThermal {
+	return &SensorThermal{
+		sensorInputs: newSensorInputs(conf, 0.001),
+		path:         cmp.Or(conf.Path, "/sys/class/thermal"),
+		sensor:       cmp.Or(conf.Sensor, "x86_pkg_temp"),
+	}
+}
+
+// Finds thermal zones by type
+func (s *SensorThermal) Init() error {
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "thermal_zone") {
+			continue
+		}
+
+		zoneDir := path.Join(s.path, entry.Name())
+		zoneType, err := ReadSysFile(path.Join(zoneDir, "type"))
+		if err != nil {
+			return fmt.Errorf("read type: %w", err)
+		}
+
+		if !matchName(s.sensor, zoneType) {
+			continue
+		}
+
+		s.files = append(s.files, path.Join(zoneDir, "temp"))
+	}
+
+	if len(s.files) == 0 {
+		return errors.New("thermal zone not found: " + s.sensor)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package drivers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+	"github.com/IvanSafonov/fanctl/internal/utils"
+)
+
+func TestSensorThermal(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := os.MkdirTemp("", "thermal")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	createFiles(t, tmpDir, map[string]string{
+		"cooling_device0/type": "Processor",
+		"thermal_zone0/type":   "acpitz",
+		"thermal_zone0/temp":   "27800",
+		"thermal_zone1/type":   "x86_pkg_temp",
+		"thermal_zone1/temp":   "45000\n",
+		"thermal_zone2/type":   "iwlwifi_1",
+		"thermal_zone2/temp":   "38000",
+		"thermal_zone3/type":   "iwlwifi_2",
+		"thermal_zone3/temp":   "40000",
+	})
+
+	s := NewSensorThermal(config.Sensor{Path: tmpDir})
+	assert.NoError(s.Init())
+
+	value, err := s.Value()
+	assert.NoError(err)
+	assert.Equal(45.0, value)
+
+	s = NewSensorThermal(config.Sensor{
+		Path:   tmpDir,
+		Sensor: "iwlwifi_*",
+		Select: models.SelectFuncMin,
+		Add:    utils.Ptr(-1.0),
+	})
+	assert.NoError(s.Init())
+
+	value, err = s.Value()
+	assert.NoError(err)
+	assert.Equal(37.0, value)
+
+	s = NewSensorThermal(config.Sensor{Path: tmpDir, Sensor: "pch"})
+	assert.Error(s.Init())
+}
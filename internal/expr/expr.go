@@ -0,0 +1,401 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
+
+// Expression over named values
+type Expr interface {
+	// Evaluates the expression with values by names
+	Eval(values map[string]float64) (float64, error)
+}
+
+// Parses an expression like max(cpu, gpu - 10) or d(cpu)/dt.
+// Supports numbers, names, + - * /, parentheses, functions
+// max, min, avg, abs and derivative d(expr)/dt per second.
+func Parse(src string) (Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+	e, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEnd {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+
+	return e, nil
+}
+
+// Returns names used in the expression
+func Names(e Expr) []string {
+	var names []string
+
+	var walk func(e Expr)
+	walk = func(e Expr) {
+		switch e := e.(type) {
+		case name:
+			if !slices.Contains(names, string(e)) {
+				names = append(names, string(e))
+			}
+		case negative:
+			walk(e.x)
+		case binary:
+			walk(e.x)
+			walk(e.y)
+		case call:
+			for _, arg := range e.args {
+				walk(arg)
+			}
+		case *derivative:
+			walk(e.x)
+		}
+	}
+
+	walk(e)
+	return names
+}
+
+// Returns names in order where dependencies go first.
+// Fails if names depend on each other in a cycle.
+func Order(deps map[string][]string) ([]string, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	keys := make([]string, 0, len(deps))
+	for name := range deps {
+		keys = append(keys, name)
+	}
+	slices.Sort(keys)
+
+	state := make(map[string]int, len(deps))
+	order := make([]string, 0, len(deps))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			start := slices.Index(path, name)
+			return fmt.Errorf("dependency cycle %v", append(path[start:], name))
+		}
+
+		state[name] = visiting
+		for _, dep := range deps[name] {
+			if _, ok := deps[dep]; !ok {
+				continue
+			}
+
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range keys {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+type number float64
+
+func (n number) Eval(map[string]float64) (float64, error) {
+	return float64(n), nil
+}
+
+type name string
+
+func (n name) Eval(values map[string]float64) (float64, error) {
+	value, ok := values[string(n)]
+	if !ok {
+		return 0, fmt.Errorf("%s has no value", string(n))
+	}
+
+	return value, nil
+}
+
+type negative struct {
+	x Expr
+}
+
+func (n negative) Eval(values map[string]float64) (float64, error) {
+	x, err := n.x.Eval(values)
+	return -x, err
+}
+
+type binary struct {
+	op   byte
+	x, y Expr
+}
+
+func (b binary) Eval(values map[string]float64) (float64, error) {
+	x, err := b.x.Eval(values)
+	if err != nil {
+		return 0, err
+	}
+
+	y, err := b.y.Eval(values)
+	if err != nil {
+		return 0, err
+	}
+
+	switch b.op {
+	case '+':
+		return x + y, nil
+	case '-':
+		return x - y, nil
+	case '*':
+		return x * y, nil
+	default:
+		if y == 0 {
+			return 0, errors.New("division by zero")
+		}
+
+		return x / y, nil
+	}
+}
+
+type call struct {
+	fn   func([]float64) float64
+	args []Expr
+}
+
+func (c call) Eval(values map[string]float64) (float64, error) {
+	args := make([]float64, 0, len(c.args))
+	for _, arg := range c.args {
+		value, err := arg.Eval(values)
+		if err != nil {
+			return 0, err
+		}
+
+		args = append(args, value)
+	}
+
+	return c.fn(args), nil
+}
+
+var functions = map[string]func([]float64) float64{
+	"max": models.SelectFunc(models.SelectFuncMax),
+	"min": models.SelectFunc(models.SelectFuncMin),
+	"avg": models.SelectAverage,
+	"abs": func(args []float64) float64 { return math.Abs(args[0]) },
+}
+
+// Rate of change per second between evaluations. Zero on the first one.
+type derivative struct {
+	x Expr
+
+	value float64
+	time  time.Time
+}
+
+func (d *derivative) Eval(values map[string]float64) (float64, error) {
+	value, err := d.x.Eval(values)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var rate float64
+	if elapsed := now.Sub(d.time).Seconds(); !d.time.IsZero() && elapsed > 0 {
+		rate = (value - d.value) / elapsed
+	}
+
+	d.value = value
+	d.time = now
+	return rate, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	t := p.next()
+	if t.kind != kind || (text != "" && t.text != text) {
+		if t.kind == tokenEnd {
+			return fmt.Errorf("expected %q at %d", text, t.pos)
+		}
+
+		return fmt.Errorf("unexpected %q at %d, expected %q", t.text, t.pos, text)
+	}
+
+	return nil
+}
+
+// sum := product (('+' | '-') product)*
+func (p *parser) parseSum() (Expr, error) {
+	x, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOp && (p.peek().text == "+" || p.peek().text == "-") {
+		op := p.next().text[0]
+		y, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+
+		x = binary{op: op, x: x, y: y}
+	}
+
+	return x, nil
+}
+
+// product := unary (('*' | '/') unary)*
+func (p *parser) parseProduct() (Expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOp && (p.peek().text == "*" || p.peek().text == "/") {
+		op := p.next().text[0]
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		x = binary{op: op, x: x, y: y}
+	}
+
+	return x, nil
+}
+
+// unary := '-' unary | primary
+func (p *parser) parseUnary() (Expr, error) {
+	if t := p.peek(); t.kind == tokenOp && t.text == "-" {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return negative{x: x}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+// primary := number | name | name '(' args ')' | 'd(' sum ')/dt' | '(' sum ')'
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenNumber:
+		return number(t.value), nil
+	case tokenLParen:
+		x, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+
+		return x, p.expect(tokenRParen, ")")
+	case tokenName:
+		if p.peek().kind != tokenLParen {
+			return name(t.text), nil
+		}
+
+		p.next()
+		if t.text == "d" {
+			return p.parseDerivative()
+		}
+
+		return p.parseCall(t)
+	case tokenEnd:
+		return nil, fmt.Errorf("unexpected end at %d", t.pos)
+	default:
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+}
+
+func (p *parser) parseDerivative() (Expr, error) {
+	x, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(tokenRParen, ")"); err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(tokenOp, "/"); err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(tokenName, "dt"); err != nil {
+		return nil, err
+	}
+
+	return &derivative{x: x}, nil
+}
+
+func (p *parser) parseCall(fnToken token) (Expr, error) {
+	fn, ok := functions[fnToken.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at %d", fnToken.text, fnToken.pos)
+	}
+
+	var args []Expr
+	for {
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg)
+
+		if p.peek().kind != tokenComma {
+			break
+		}
+
+		p.next()
+	}
+
+	if err := p.expect(tokenRParen, ")"); err != nil {
+		return nil, err
+	}
+
+	if fnToken.text == "abs" && len(args) != 1 {
+		return nil, fmt.Errorf("function abs at %d: expected 1 argument", fnToken.pos)
+	}
+
+	return call{fn: fn, args: args}, nil
+}
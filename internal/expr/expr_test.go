@@ -0,0 +1,120 @@
+package expr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEval(t *testing.T) {
+	values := map[string]float64{"cpu": 60, "gpu": 75, "nvme": 40, "ambient": 25}
+
+	cases := []struct {
+		expr  string
+		value float64
+	}{
+		{"42", 42},
+		{"cpu", 60},
+		{"max(cpu, gpu - 10)", 65},
+		{"min(cpu, gpu, nvme)", 40},
+		{"avg(cpu, nvme)", 50},
+		{"abs(ambient - cpu)", 35},
+		{"nvme * 0.5 + ambient", 45},
+		{"(cpu + gpu) / 3", 45},
+		{"-cpu + 2 * 3", -54},
+		{"cpu - nvme - ambient", -5},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			e, err := Parse(tc.expr)
+			require.NoError(t, err)
+
+			value, err := e.Eval(values)
+			assert.NoError(t, err)
+			assert.InDelta(t, tc.value, value, 0.0001)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		expr string
+		err  string
+	}{
+		{"", "unexpected end at 0"},
+		{"cpu +", "unexpected end at 5"},
+		{"max(cpu", `expected ")" at 7`},
+		{"cpu gpu", `unexpected "gpu" at 4`},
+		{"pow(cpu, 2)", `unknown function "pow" at 0`},
+		{"abs(cpu, gpu)", "function abs at 0: expected 1 argument"},
+		{"d(cpu)", `expected "/" at 6`},
+		{"d(cpu)/dx", `unexpected "dx" at 7, expected "dt"`},
+		{"cpu # 2", `unexpected '#' at 4`},
+		{"1.2.3", `invalid number "1.2.3" at 0`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(tc.expr)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	e, err := Parse("cpu / gpu")
+	require.NoError(t, err)
+
+	_, err = e.Eval(map[string]float64{"cpu": 1})
+	assert.EqualError(t, err, "gpu has no value")
+
+	_, err = e.Eval(map[string]float64{"cpu": 1, "gpu": 0})
+	assert.EqualError(t, err, "division by zero")
+}
+
+func TestDerivative(t *testing.T) {
+	assert := assert.New(t)
+
+	e, err := Parse("d(cpu)/dt")
+	require.NoError(t, err)
+
+	value, err := e.Eval(map[string]float64{"cpu": 50})
+	assert.NoError(err)
+	assert.Equal(0.0, value)
+
+	d := e.(*derivative)
+	d.time = time.Now().Add(-2 * time.Second)
+
+	value, err = e.Eval(map[string]float64{"cpu": 60})
+	assert.NoError(err)
+	assert.InDelta(5.0, value, 0.01)
+}
+
+func TestNames(t *testing.T) {
+	e, err := Parse("max(cpu, d(gpu)/dt * 10, cpu - nvme)")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"cpu", "gpu", "nvme"}, Names(e))
+}
+
+func TestOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	order, err := Order(map[string][]string{
+		"a": {"b", "cpu"},
+		"b": {"c"},
+		"c": {"gpu"},
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"c", "b", "a"}, order)
+
+	_, err = Order(map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"b"},
+	})
+	assert.EqualError(err, "dependency cycle [b c b]")
+}
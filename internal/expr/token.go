@@ -0,0 +1,82 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenName
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value float64
+	pos   int
+}
+
+// Splits the expression to tokens. The last token is always tokenEnd.
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case unicode.IsDigit(r) || r == '.':
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			text := string(runes[start:i])
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", text, start)
+			}
+
+			tokens = append(tokens, token{kind: tokenNumber, text: text, value: value, pos: start})
+			continue
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+
+			tokens = append(tokens, token{kind: tokenName, text: string(runes[start:i]), pos: start})
+			continue
+		}
+
+		var kind tokenKind
+		switch r {
+		case '+', '-', '*', '/':
+			kind = tokenOp
+		case '(':
+			kind = tokenLParen
+		case ')':
+			kind = tokenRParen
+		case ',':
+			kind = tokenComma
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", r, start)
+		}
+
+		tokens = append(tokens, token{kind: kind, text: string(r), pos: start})
+		i++
+	}
+
+	return append(tokens, token{kind: tokenEnd, pos: len(runes)}), nil
+}
@@ -1,17 +1,71 @@
 package models
 
+import "regexp"
+
 const (
 	FanTypeThinkpad = "thinkpad"
+	FanTypeHwmon    = "hwmon"
+	FanTypeCooling  = "cooling"
+	FanTypeDell     = "dell"
+	FanTypeCommand  = "command"
+	FanTypeFile     = "file"
+
+	SensorTypeHwmon       = "hwmon"
+	SensorTypeThermal     = "thermal"
+	SensorTypeFile        = "file"
+	SensorTypeCommand     = "command"
+	SensorTypeCPULoad     = "cpuload"
+	SensorTypeRAPL        = "rapl"
+	SensorTypePowerSupply = "power_supply"
+	SensorTypeVirtual     = "virtual"
+	SensorTypeDisk        = "disk"
+
+	ProfileTypePlatform    = "platform"
+	ProfileTypePowerSupply = "power_supply"
+	ProfileTypePPD         = "ppd"
+
+	CPULoadModeTotal = "total"
+	CPULoadModeCores = "cores"
 
-	SensorTypeHwmon = "hwmon"
+	PowerSupplyModeTemp     = "temp"
+	PowerSupplyModeCapacity = "capacity"
+	PowerSupplyModeOnline   = "online"
 
-	ProfileTypePlatform = "platform"
+	HwmonClassTemp  = "temp"
+	HwmonClassFan   = "fan"
+	HwmonClassPower = "power"
+	HwmonClassIn    = "in"
+	HwmonClassCurr  = "curr"
+
+	DiskStandbyLast = "last"
+	DiskStandbySkip = "skip"
+
+	SensorOnErrorKeep     = "keep"
+	SensorOnErrorDrop     = "drop"
+	SensorOnErrorFailsafe = "failsafe"
 )
 
 var (
-	FanTypes = []string{FanTypeThinkpad}
+	FanTypes = []string{FanTypeThinkpad, FanTypeHwmon, FanTypeCooling, FanTypeDell, FanTypeCommand, FanTypeFile}
+
+	SensorTypes = []string{SensorTypeHwmon, SensorTypeThermal, SensorTypeFile, SensorTypeCommand, SensorTypeCPULoad, SensorTypeRAPL,
+		SensorTypePowerSupply, SensorTypeVirtual, SensorTypeDisk}
+
+	// Sensor types which read input files and support validMin, validMax and maxStep
+	SensorGuardTypes = []string{SensorTypeHwmon, SensorTypeThermal, SensorTypeFile, SensorTypePowerSupply, SensorTypeDisk}
+
+	ProfileTypes = []string{ProfileTypePlatform, ProfileTypePowerSupply, ProfileTypePPD}
+
+	CPULoadModes = []string{CPULoadModeTotal, CPULoadModeCores}
+
+	PowerSupplyModes = []string{PowerSupplyModeTemp, PowerSupplyModeCapacity, PowerSupplyModeOnline}
+
+	HwmonClasses = []string{HwmonClassTemp, HwmonClassFan, HwmonClassPower, HwmonClassIn, HwmonClassCurr}
+
+	// Hwmon input name like temp1, the submatch is the input class
+	HwmonInputRegex = regexp.MustCompile(`^([a-z]+)[0-9]+$`)
 
-	SensorTypes = []string{SensorTypeHwmon}
+	DiskStandbyModes = []string{DiskStandbyLast, DiskStandbySkip}
 
-	ProfileTypes = []string{ProfileTypePlatform}
+	SensorOnErrors = []string{SensorOnErrorKeep, SensorOnErrorDrop, SensorOnErrorFailsafe}
 )
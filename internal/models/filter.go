@@ -0,0 +1,11 @@
+package models
+
+const (
+	FilterTypeEMA    = "ema"
+	FilterTypeMean   = "mean"
+	FilterTypeMedian = "median"
+)
+
+var (
+	FilterTypes = []string{FilterTypeEMA, FilterTypeMean, FilterTypeMedian}
+)
@@ -12,6 +12,24 @@ type FanDriver interface {
 	Defaults() drivers.FanDefaults
 }
 
+// Optional fan driver interface. Gives fan control back to the system
+// when the service stops.
+type FanRestorer interface {
+	Restore() error
+}
+
+// Optional fan driver interface. Reads actual fan state back from the driver.
+// Drivers return errors.ErrUnsupported if the state is not available.
+type FanStateReader interface {
+	State() (drivers.FanState, error)
+}
+
+// Optional fan driver interface. Tells if the level stops or spins the fan.
+// Drivers without it have numeric levels.
+type FanLevelClassifier interface {
+	LevelKind(level string) drivers.LevelKind
+}
+
 type ProfileDriver interface {
 	Init() error
 	State() (string, error)
@@ -22,7 +40,7 @@ type SensorDriver interface {
 	Value() (float64, error)
 }
 
-//go:generate mockgen -package service -destination ./drivers_mock_test.go . FanDriver,ProfileDriver,SensorDriver
+//go:generate mockgen -package service -destination ./drivers_mock_test.go . FanDriver,FanRestorer,FanStateReader,FanLevelClassifier,ProfileDriver,SensorDriver
 
 func createProfile(conf *config.Profile) ProfileDriver {
 	if conf == nil {
@@ -32,6 +50,10 @@ func createProfile(conf *config.Profile) ProfileDriver {
 	switch conf.Type {
 	case models.ProfileTypePlatform:
 		return drivers.NewProfilePlatform(*conf)
+	case models.ProfileTypePowerSupply:
+		return drivers.NewProfilePowerSupply(*conf)
+	case models.ProfileTypePPD:
+		return drivers.NewProfilePPD(*conf)
 	}
 
 	return nil
@@ -44,6 +66,20 @@ func createSensors(confs []config.Sensor) map[string]SensorDriver {
 		switch conf.Type {
 		case models.SensorTypeHwmon:
 			sensors[conf.Name] = drivers.NewSensorHwmon(conf)
+		case models.SensorTypeThermal:
+			sensors[conf.Name] = drivers.NewSensorThermal(conf)
+		case models.SensorTypeFile:
+			sensors[conf.Name] = drivers.NewSensorFile(conf)
+		case models.SensorTypeCommand:
+			sensors[conf.Name] = drivers.NewSensorCommand(conf)
+		case models.SensorTypeCPULoad:
+			sensors[conf.Name] = drivers.NewSensorCPULoad(conf)
+		case models.SensorTypeRAPL:
+			sensors[conf.Name] = drivers.NewSensorRAPL(conf)
+		case models.SensorTypePowerSupply:
+			sensors[conf.Name] = drivers.NewSensorPowerSupply(conf)
+		case models.SensorTypeDisk:
+			sensors[conf.Name] = drivers.NewSensorDisk(conf)
 		}
 	}
 
@@ -58,6 +94,21 @@ func createFans(confs []config.Fan) []Fan {
 		case models.FanTypeThinkpad:
 			driver := drivers.NewFanThinkpad(conf)
 			fans = append(fans, NewFan(driver, conf))
+		case models.FanTypeHwmon:
+			driver := drivers.NewFanHwmon(conf)
+			fans = append(fans, NewFan(driver, conf))
+		case models.FanTypeCooling:
+			driver := drivers.NewFanCooling(conf)
+			fans = append(fans, NewFan(driver, conf))
+		case models.FanTypeDell:
+			driver := drivers.NewFanDell(conf)
+			fans = append(fans, NewFan(driver, conf))
+		case models.FanTypeCommand:
+			driver := drivers.NewFanCommand(conf)
+			fans = append(fans, NewFan(driver, conf))
+		case models.FanTypeFile:
+			driver := drivers.NewFanFile(conf)
+			fans = append(fans, NewFan(driver, conf))
 		}
 	}
 
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/IvanSafonov/fanctl/internal/service (interfaces: FanDriver,ProfileDriver,SensorDriver)
+// Source: github.com/IvanSafonov/fanctl/internal/service (interfaces: FanDriver,FanRestorer,FanStateReader,FanLevelClassifier,ProfileDriver,SensorDriver)
 //
 // Generated by this command:
 //
-//	mockgen -package service -destination ./drivers_mock_test.go . FanDriver,ProfileDriver,SensorDriver
+//	mockgen -package service -destination ./drivers_mock_test.go . FanDriver,FanRestorer,FanStateReader,FanLevelClassifier,ProfileDriver,SensorDriver
 //
 
 // Package service is a generated GoMock package.
@@ -81,6 +81,118 @@ func (mr *MockFanDriverMockRecorder) SetLevel(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLevel", reflect.TypeOf((*MockFanDriver)(nil).SetLevel), arg0)
 }
 
+// MockFanRestorer is a mock of FanRestorer interface.
+type MockFanRestorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockFanRestorerMockRecorder
+}
+
+// MockFanRestorerMockRecorder is the mock recorder for MockFanRestorer.
+type MockFanRestorerMockRecorder struct {
+	mock *MockFanRestorer
+}
+
+// NewMockFanRestorer creates a new mock instance.
+func NewMockFanRestorer(ctrl *gomock.Controller) *MockFanRestorer {
+	mock := &MockFanRestorer{ctrl: ctrl}
+	mock.recorder = &MockFanRestorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFanRestorer) EXPECT() *MockFanRestorerMockRecorder {
+	return m.recorder
+}
+
+// Restore mocks base method.
+func (m *MockFanRestorer) Restore() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockFanRestorerMockRecorder) Restore() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockFanRestorer)(nil).Restore))
+}
+
+// MockFanStateReader is a mock of FanStateReader interface.
+type MockFanStateReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockFanStateReaderMockRecorder
+}
+
+// MockFanStateReaderMockRecorder is the mock recorder for MockFanStateReader.
+type MockFanStateReaderMockRecorder struct {
+	mock *MockFanStateReader
+}
+
+// NewMockFanStateReader creates a new mock instance.
+func NewMockFanStateReader(ctrl *gomock.Controller) *MockFanStateReader {
+	mock := &MockFanStateReader{ctrl: ctrl}
+	mock.recorder = &MockFanStateReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFanStateReader) EXPECT() *MockFanStateReaderMockRecorder {
+	return m.recorder
+}
+
+// State mocks base method.
+func (m *MockFanStateReader) State() (drivers.FanState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "State")
+	ret0, _ := ret[0].(drivers.FanState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// State indicates an expected call of State.
+func (mr *MockFanStateReaderMockRecorder) State() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockFanStateReader)(nil).State))
+}
+
+// MockFanLevelClassifier is a mock of FanLevelClassifier interface.
+type MockFanLevelClassifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockFanLevelClassifierMockRecorder
+}
+
+// MockFanLevelClassifierMockRecorder is the mock recorder for MockFanLevelClassifier.
+type MockFanLevelClassifierMockRecorder struct {
+	mock *MockFanLevelClassifier
+}
+
+// NewMockFanLevelClassifier creates a new mock instance.
+func NewMockFanLevelClassifier(ctrl *gomock.Controller) *MockFanLevelClassifier {
+	mock := &MockFanLevelClassifier{ctrl: ctrl}
+	mock.recorder = &MockFanLevelClassifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFanLevelClassifier) EXPECT() *MockFanLevelClassifierMockRecorder {
+	return m.recorder
+}
+
+// LevelKind mocks base method.
+func (m *MockFanLevelClassifier) LevelKind(arg0 string) drivers.LevelKind {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LevelKind", arg0)
+	ret0, _ := ret[0].(drivers.LevelKind)
+	return ret0
+}
+
+// LevelKind indicates an expected call of LevelKind.
+func (mr *MockFanLevelClassifierMockRecorder) LevelKind(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LevelKind", reflect.TypeOf((*MockFanLevelClassifier)(nil).LevelKind), arg0)
+}
+
 // MockProfileDriver is a mock of ProfileDriver interface.
 type MockProfileDriver struct {
 	ctrl     *gomock.Controller
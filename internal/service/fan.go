@@ -2,27 +2,45 @@ package service
 
 import (
 	"cmp"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/drivers"
 	"github.com/IvanSafonov/fanctl/internal/models"
 )
 
 type Fan struct {
 	Name string
 
-	driver          FanDriver
-	repeat          time.Duration
-	defaultLevel    string
-	suspendLevel    string
-	defaultLevels   Levels
-	profileLevels   map[string]Levels
-	selectValueFunc func(map[string]float64) float64
+	driver         FanDriver
+	repeat         time.Duration
+	defaultLevel   string
+	suspendLevel   string
+	emergencyLevel string
+	stallTimeout   time.Duration
 
-	levels  Levels
-	updated time.Time
+	kickstartLevel    string
+	kickstartDuration time.Duration
+	defaultLevels     Levels
+	profileLevels     map[string]Levels
+	failsafeLevel     string
+	sensors           []string
+	selectValueFunc   func(map[string]float64) (float64, bool)
+
+	levels         Levels
+	level          string
+	updated        time.Time
+	kickstartUntil time.Time
+	failures       int
+	failsafe       bool
+	idle           bool
+	emergency      bool
+	stalled        bool
+	stalledSince   time.Time
 }
 
 func NewFan(driver FanDriver, conf config.Fan) Fan {
@@ -36,27 +54,42 @@ func NewFan(driver FanDriver, conf config.Fan) Fan {
 	}
 
 	selectFunc := models.SelectFunc(conf.Select)
-	var selectValueFunc func(map[string]float64) float64
+	var selectValueFunc func(map[string]float64) (float64, bool)
 	if len(conf.Sensors) == 0 {
-		selectValueFunc = func(values map[string]float64) float64 {
-			return selectFunc(allValues(values))
+		selectValueFunc = func(values map[string]float64) (float64, bool) {
+			return selectValue(selectFunc, allValues(values))
 		}
 	} else {
-		selectValueFunc = func(values map[string]float64) float64 {
-			return selectFunc(namedValues(values, conf.Sensors))
+		selectValueFunc = func(values map[string]float64) (float64, bool) {
+			return selectValue(selectFunc, namedValues(values, conf.Sensors))
+		}
+	}
+
+	var kickstartLevel string
+	kickstartDuration := 2 * time.Second
+	if conf.Kickstart != nil {
+		kickstartLevel = conf.Kickstart.Level
+		if conf.Kickstart.Duration != nil {
+			kickstartDuration = conf.Kickstart.Duration.Duration()
 		}
 	}
 
 	return Fan{
-		Name:            conf.Name,
-		driver:          driver,
-		repeat:          defaults.Repeat.Duration(),
-		levels:          levels,
-		defaultLevel:    defaults.Level,
-		suspendLevel:    defaults.SuspendLevel,
-		defaultLevels:   levels,
-		profileLevels:   profileLevels,
-		selectValueFunc: selectValueFunc,
+		Name:              conf.Name,
+		driver:            driver,
+		repeat:            defaults.Repeat.Duration(),
+		levels:            levels,
+		defaultLevel:      defaults.Level,
+		suspendLevel:      defaults.SuspendLevel,
+		emergencyLevel:    defaults.EmergencyLevel,
+		stallTimeout:      defaults.StallTimeout.Duration(),
+		kickstartLevel:    kickstartLevel,
+		kickstartDuration: kickstartDuration,
+		defaultLevels:     levels,
+		profileLevels:     profileLevels,
+		failsafeLevel:     defaults.FailsafeLevel,
+		sensors:           conf.Sensors,
+		selectValueFunc:   selectValueFunc,
 	}
 }
 
@@ -76,30 +109,187 @@ func (f *Fan) UpdateProfile(profile string) {
 // Updates fan level according to sensors values
 // - select sensor value
 // - check and update current level
-// - update driver level if level is changed or need to repeat
+// - use failsafe level if sensors failed or there are no values
+// - keep the current or default level if all sensors are idle
+// - use emergency level if another fan is stalled
+// - update driver level if level is changed, kickstart is over or need to repeat
+// - use kickstart level first if the fan starts from the stopped level
+// - check if the fan is stalled
 func (f *Fan) UpdateLevel(values map[string]float64) error {
-	value := f.selectValueFunc(values)
-	if !f.levels.Update(value) && time.Since(f.updated) < f.repeat {
-		return nil
+	value, ok := f.selectValueFunc(values)
+
+	var level string
+	switch {
+	case f.failsafe:
+		level = f.failsafeLevel
+	case !ok && f.idle:
+		level = cmp.Or(f.level, f.defaultLevel)
+	case !ok:
+		level = f.failsafeLevel
+	default:
+		f.levels.Update(value)
+		level = f.levels.Level()
 	}
 
-	level := f.levels.Level()
-	slog.Info("update level", "fan", f.Name, "level", level, "value", value)
+	if f.emergency {
+		level = f.emergencyLevel
+	}
 
-	if err := f.driver.SetLevel(level); err != nil {
-		return fmt.Errorf("set fan (%s) level: %w", f.Name, err)
+	kickstartOver := !f.kickstartUntil.IsZero() && !time.Now().Before(f.kickstartUntil)
+	repeat := f.kickstartUntil.IsZero() && time.Since(f.updated) >= f.repeat
+
+	if level != f.level || kickstartOver || repeat {
+		driverLevel := level
+		kickstart := f.kickstartLevel != "" && f.isStoppedLevel(f.level) && f.levelKind(level) == drivers.LevelRunning
+		if kickstart {
+			driverLevel = f.kickstartLevel
+		}
+
+		slog.Info("update level", "fan", f.Name, "level", driverLevel, "value", value)
+
+		if err := f.driver.SetLevel(driverLevel); err != nil {
+			f.failures++
+			return fmt.Errorf("set fan (%s) level: %w", f.Name, err)
+		}
+
+		if f.failures != 0 {
+			slog.Info("fan recovered", "fan", f.Name, "failures", f.failures)
+			f.failures = 0
+		}
+
+		f.level = level
+		f.updated = time.Now()
+		f.kickstartUntil = time.Time{}
+		if kickstart {
+			f.kickstartUntil = f.updated.Add(f.kickstartDuration)
+		}
+
+		f.checkState(driverLevel)
 	}
 
-	f.updated = time.Now()
+	f.checkStall()
 	return nil
 }
 
+// Switches the fan to failsafe level or back to normal levels
+func (f *Fan) SetFailsafe(failsafe bool) {
+	if failsafe != f.failsafe {
+		slog.Warn("fan failsafe mode", "fan", f.Name, "enabled", failsafe)
+	}
+
+	f.failsafe = failsafe
+}
+
+// Marks that sensors without values are idle, not failed
+func (f *Fan) SetIdle(idle bool) {
+	f.idle = idle
+}
+
+// Returns true if the fan level depends on the sensor
+func (f *Fan) UsesSensor(name string) bool {
+	return len(f.sensors) == 0 || slices.Contains(f.sensors, name)
+}
+
+// Switches the fan to emergency level or back to normal levels
+func (f *Fan) SetEmergency(emergency bool) {
+	f.emergency = emergency
+}
+
+// Returns true if the fan doesn't spin at running level
+func (f *Fan) Stalled() bool {
+	return f.stalled
+}
+
+// Reads fan speed from the driver. The fan is stalled if it has zero speed at
+// running level for longer than stall timeout. Drivers without speed are never stalled.
+func (f *Fan) checkStall() {
+	reader, ok := f.driver.(FanStateReader)
+	if !ok || f.updated.IsZero() {
+		return
+	}
+
+	if f.levelKind(f.level) != drivers.LevelRunning {
+		f.stalledSince = time.Time{}
+		f.stalled = false
+		return
+	}
+
+	state, err := reader.State()
+	if err != nil {
+		return
+	}
+
+	// The driver doesn't report speed, e.g. file fan with readBack
+	if state.Speed == nil {
+		f.stalledSince = time.Time{}
+		f.stalled = false
+		return
+	}
+
+	if *state.Speed > 0 {
+		if f.stalled {
+			slog.Info("fan is spinning again", "fan", f.Name, "speed", *state.Speed)
+		}
+
+		f.stalledSince = time.Time{}
+		f.stalled = false
+		return
+	}
+
+	if f.stalledSince.IsZero() {
+		f.stalledSince = time.Now()
+	}
+
+	if !f.stalled && time.Since(f.stalledSince) >= f.stallTimeout {
+		slog.Error("fan is stalled", "fan", f.Name, "level", f.level)
+		f.stalled = true
+	}
+}
+
+// Reads fan state back from the driver and logs if the driver
+// applied a different level, e.g. when fan control is disabled.
+func (f *Fan) checkState(level string) {
+	reader, ok := f.driver.(FanStateReader)
+	if !ok {
+		return
+	}
+
+	state, err := reader.State()
+	if err != nil {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			slog.Warn("failed to read fan state", "fan", f.Name, "error", err)
+		}
+		return
+	}
+
+	if state.Speed != nil {
+		slog.Debug("fan state", "fan", f.Name, "status", state.Status, "speed", *state.Speed, "level", state.Level)
+	} else {
+		slog.Debug("fan state", "fan", f.Name, "status", state.Status, "level", state.Level)
+	}
+
+	if state.Level != "" && state.Level != level {
+		slog.Warn("fan level mismatch", "fan", f.Name, "level", level, "actual", state.Level)
+	}
+}
+
 func (f *Fan) SetDefaultLevel() {
 	slog.Info("set default level", "fan", f.Name, "level", f.defaultLevel)
 
 	if err := f.driver.SetLevel(f.defaultLevel); err != nil {
 		slog.Error("failed to set default level", "error", err)
 	}
+
+	f.Restore()
+}
+
+// Gives fan control back to the system if the driver supports it
+func (f *Fan) Restore() {
+	if restorer, ok := f.driver.(FanRestorer); ok {
+		if err := restorer.Restore(); err != nil {
+			slog.Error("failed to restore fan control", "fan", f.Name, "error", err)
+		}
+	}
 }
 
 func (f *Fan) SetSuspendLevel() {
@@ -110,6 +300,20 @@ func (f *Fan) SetSuspendLevel() {
 	}
 }
 
+// Levels which stop the fan. Empty level is unknown state on start.
+func (f *Fan) isStoppedLevel(level string) bool {
+	return level == "" || f.levelKind(level) == drivers.LevelStopped
+}
+
+// Returns driver level kind, numeric levels by default
+func (f *Fan) levelKind(level string) drivers.LevelKind {
+	if classifier, ok := f.driver.(FanLevelClassifier); ok {
+		return classifier.LevelKind(level)
+	}
+
+	return drivers.NumericLevelKind(level)
+}
+
 func allValues(values map[string]float64) []float64 {
 	result := make([]float64, 0, len(values))
 	for _, value := range values {
@@ -119,21 +323,35 @@ func allValues(values map[string]float64) []float64 {
 	return result
 }
 
+// Skips sensors without values
 func namedValues(values map[string]float64, names []string) []float64 {
 	result := make([]float64, 0, len(values))
 	for _, name := range names {
-		result = append(result, values[name])
+		if value, ok := values[name]; ok {
+			result = append(result, value)
+		}
 	}
 
 	return result
 }
 
+func selectValue(selectFunc func([]float64) float64, values []float64) (float64, bool) {
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	return selectFunc(values), true
+}
+
 type FanDefaults struct {
-	Level        string
-	SuspendLevel string
-	Repeat       models.Seconds
-	DelayUp      *models.Seconds
-	DelayDown    *models.Seconds
+	Level          string
+	SuspendLevel   string
+	EmergencyLevel string
+	FailsafeLevel  string
+	Repeat         models.Seconds
+	StallTimeout   models.Seconds
+	DelayUp        *models.Seconds
+	DelayDown      *models.Seconds
 }
 
 func NewFanDefaults(driver FanDriver, conf config.Fan) FanDefaults {
@@ -142,12 +360,22 @@ func NewFanDefaults(driver FanDriver, conf config.Fan) FanDefaults {
 		drvDefaults.Repeat = *conf.Repeat
 	}
 
+	stallTimeout := models.Seconds(10)
+	if conf.StallTimeout != nil {
+		stallTimeout = *conf.StallTimeout
+	}
+
+	level := cmp.Or(conf.Level, drvDefaults.Level)
+
 	return FanDefaults{
-		Level:        cmp.Or(conf.Level, drvDefaults.Level),
-		SuspendLevel: cmp.Or(conf.SuspendLevel, drvDefaults.Level),
-		Repeat:       drvDefaults.Repeat,
-		DelayUp:      cmp.Or(conf.DelayUp, conf.Delay),
-		DelayDown:    cmp.Or(conf.DelayDown, conf.Delay),
+		Level:          level,
+		SuspendLevel:   cmp.Or(conf.SuspendLevel, drvDefaults.Level),
+		EmergencyLevel: cmp.Or(conf.EmergencyLevel, level),
+		FailsafeLevel:  cmp.Or(conf.FailsafeLevel, level),
+		Repeat:         drvDefaults.Repeat,
+		StallTimeout:   stallTimeout,
+		DelayUp:        cmp.Or(conf.DelayUp, conf.Delay),
+		DelayDown:      cmp.Or(conf.DelayDown, conf.Delay),
 	}
 }
 
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/drivers"
+	"github.com/IvanSafonov/fanctl/internal/models"
+	"github.com/IvanSafonov/fanctl/internal/utils"
+)
+
+type stateFanDriver struct {
+	*MockFanDriver
+	*MockFanStateReader
+}
+
+func TestFanUpdateLevelReadsState(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	driver := NewMockFanDriver(ctrl)
+	driver.EXPECT().Defaults().Return(drivers.FanDefaults{Repeat: 1000, Level: "auto"})
+	reader := NewMockFanStateReader(ctrl)
+
+	fan := NewFan(stateFanDriver{driver, reader}, config.Fan{
+		Levels: []config.Level{
+			{Level: "0", Max: utils.Ptr(50.0)},
+		},
+	})
+
+	gomock.InOrder(
+		driver.EXPECT().SetLevel("0"),
+		reader.EXPECT().State().Return(drivers.FanState{Status: "enabled", Level: "auto"}, nil),
+	)
+
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 40}))
+}
+
+func TestFanStall(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	driver := NewMockFanDriver(ctrl)
+	driver.EXPECT().Defaults().Return(drivers.FanDefaults{Repeat: 1000, Level: "auto"})
+	reader := NewMockFanStateReader(ctrl)
+
+	fan := NewFan(stateFanDriver{driver, reader}, config.Fan{
+		Levels: []config.Level{
+			{Level: "0", Max: utils.Ptr(50.0)},
+			{Level: "3", Min: utils.Ptr(50.0)},
+		},
+	})
+
+	// Stopped fan is not stalled
+	driver.EXPECT().SetLevel("0")
+	reader.EXPECT().State().Return(drivers.FanState{Level: "0"}, nil)
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 40}))
+	assert.False(fan.Stalled())
+
+	// Zero speed during stall timeout
+	driver.EXPECT().SetLevel("3")
+	reader.EXPECT().State().Return(drivers.FanState{Level: "3", Speed: utils.Ptr(0)}, nil).Times(2)
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 60}))
+	assert.False(fan.Stalled())
+	assert.False(fan.stalledSince.IsZero())
+
+	fan.stalledSince = fan.stalledSince.Add(-fan.stallTimeout)
+	reader.EXPECT().State().Return(drivers.FanState{Level: "3", Speed: utils.Ptr(0)}, nil)
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 60}))
+	assert.True(fan.Stalled())
+
+	// Recovery
+	reader.EXPECT().State().Return(drivers.FanState{Level: "3", Speed: utils.Ptr(1200)}, nil)
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 60}))
+	assert.False(fan.Stalled())
+}
+
+func TestFanStallWithoutSpeed(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	driver := NewMockFanDriver(ctrl)
+	driver.EXPECT().Defaults().Return(drivers.FanDefaults{Repeat: 1000, Level: "42"})
+	reader := NewMockFanStateReader(ctrl)
+
+	fan := NewFan(stateFanDriver{driver, reader}, config.Fan{})
+
+	// File fan with readBack reports only level
+	driver.EXPECT().SetLevel("42")
+	reader.EXPECT().State().Return(drivers.FanState{Level: "42"}, nil).Times(2)
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 40}))
+
+	fan.stallTimeout = 0
+	reader.EXPECT().State().Return(drivers.FanState{Level: "42"}, nil)
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 40}))
+	assert.False(fan.Stalled())
+	assert.True(fan.stalledSince.IsZero())
+}
+
+func TestFanLevelKind(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	driver := NewMockFanDriver(ctrl)
+	classifier := NewMockFanLevelClassifier(ctrl)
+
+	fan := Fan{driver: driver}
+	assert.True(fan.isStoppedLevel(""))
+	assert.True(fan.isStoppedLevel("0"))
+	assert.Equal(drivers.LevelRunning, fan.levelKind("7"))
+	assert.Equal(drivers.LevelUnknown, fan.levelKind("full-speed"))
+
+	fan = Fan{driver: struct {
+		FanDriver
+		FanLevelClassifier
+	}{driver, classifier}}
+	classifier.EXPECT().LevelKind("full-speed").Return(drivers.LevelRunning)
+	assert.Equal(drivers.LevelRunning, fan.levelKind("full-speed"))
+}
+
+func TestFanEmergency(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	driver := NewMockFanDriver(ctrl)
+	driver.EXPECT().Defaults().Return(drivers.FanDefaults{Repeat: 1000, Level: "auto"})
+
+	fan := NewFan(driver, config.Fan{
+		EmergencyLevel: "7",
+		Levels: []config.Level{
+			{Level: "0", Max: utils.Ptr(50.0)},
+		},
+	})
+
+	driver.EXPECT().SetLevel("0")
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 40}))
+
+	fan.SetEmergency(true)
+	driver.EXPECT().SetLevel("7")
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 40}))
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 40}))
+
+	fan.SetEmergency(false)
+	driver.EXPECT().SetLevel("0")
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 40}))
+}
+
+func TestFanKickstart(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	driver := NewMockFanDriver(ctrl)
+	driver.EXPECT().Defaults().Return(drivers.FanDefaults{Repeat: 1000, Level: "auto"})
+
+	fan := NewFan(driver, config.Fan{
+		Kickstart: &config.Kickstart{Level: "7", Duration: models.SecondsPtr(1000)},
+		Levels: []config.Level{
+			{Level: "0", Max: utils.Ptr(50.0)},
+			{Level: "2", Min: utils.Ptr(50.0), Max: utils.Ptr(60.0)},
+			{Level: "4", Min: utils.Ptr(60.0)},
+		},
+	})
+
+	driver.EXPECT().SetLevel("0")
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 40}))
+
+	// Boost level first
+	driver.EXPECT().SetLevel("7")
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 55}))
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 55}))
+
+	// Target level after kickstart duration
+	fan.kickstartUntil = time.Now()
+	driver.EXPECT().SetLevel("2")
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 55}))
+	assert.True(fan.kickstartUntil.IsZero())
+
+	// No kickstart between running levels
+	driver.EXPECT().SetLevel("4")
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 65}))
+	assert.True(fan.kickstartUntil.IsZero())
+}
+
+func TestFanEmergencyDefaultLevel(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	driver := NewMockFanDriver(ctrl)
+	driver.EXPECT().Defaults().Return(drivers.FanDefaults{Repeat: 60})
+
+	fan := NewFan(driver, config.Fan{Level: "normal"})
+
+	fan.SetEmergency(true)
+	driver.EXPECT().SetLevel("normal")
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 40}))
+}
+
+func TestFanFailsafeDefaultLevel(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	// Command and file drivers have no default level
+	driver := NewMockFanDriver(ctrl)
+	driver.EXPECT().Defaults().Return(drivers.FanDefaults{Repeat: 60})
+
+	fan := NewFan(driver, config.Fan{
+		Level: "normal",
+		Levels: []config.Level{
+			{Level: "quiet", Max: utils.Ptr(50.0)},
+		},
+	})
+	assert.Equal("normal", fan.failsafeLevel)
+	assert.Equal("normal", fan.emergencyLevel)
+
+	fan.SetFailsafe(true)
+	driver.EXPECT().SetLevel("normal")
+	assert.NoError(fan.UpdateLevel(map[string]float64{"cpu": 40}))
+}
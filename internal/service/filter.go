@@ -0,0 +1,112 @@
+package service
+
+import (
+	"slices"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
+
+// Smooths sensor values between updates
+type Filter interface {
+	// Adds the value and returns filtered value
+	Update(value float64) float64
+}
+
+func NewFilter(conf config.Filter) Filter {
+	window := 5
+	if conf.Window != nil {
+		window = *conf.Window
+	}
+
+	switch conf.Type {
+	case models.FilterTypeEMA:
+		alpha := 0.5
+		if conf.Alpha != nil {
+			alpha = *conf.Alpha
+		}
+
+		return &emaFilter{alpha: alpha}
+	case models.FilterTypeMedian:
+		return &medianFilter{window: newWindow(window)}
+	default:
+		return &meanFilter{window: newWindow(window)}
+	}
+}
+
+func createFilters(confs []config.Sensor) map[string]Filter {
+	filters := make(map[string]Filter)
+	for _, conf := range confs {
+		if conf.Filter != nil {
+			filters[conf.Name] = NewFilter(*conf.Filter)
+		}
+	}
+
+	return filters
+}
+
+// Exponential moving average
+type emaFilter struct {
+	alpha  float64
+	value  float64
+	filled bool
+}
+
+func (f *emaFilter) Update(value float64) float64 {
+	if !f.filled {
+		f.value = value
+		f.filled = true
+		return value
+	}
+
+	f.value += f.alpha * (value - f.value)
+	return f.value
+}
+
+// Average of the last N values
+type meanFilter struct {
+	window
+}
+
+func (f *meanFilter) Update(value float64) float64 {
+	return models.SelectAverage(f.add(value))
+}
+
+// Median of the last N values
+type medianFilter struct {
+	window
+}
+
+func (f *medianFilter) Update(value float64) float64 {
+	values := slices.Clone(f.add(value))
+	slices.Sort(values)
+
+	middle := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[middle-1] + values[middle]) / 2
+	}
+
+	return values[middle]
+}
+
+// Last N values
+type window struct {
+	values []float64
+	size   int
+}
+
+func newWindow(size int) window {
+	return window{
+		values: make([]float64, 0, size),
+		size:   size,
+	}
+}
+
+func (w *window) add(value float64) []float64 {
+	if len(w.values) == w.size {
+		w.values = slices.Delete(w.values, 0, 1)
+	}
+
+	w.values = append(w.values, value)
+	return w.values
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/models"
+	"github.com/IvanSafonov/fanctl/internal/utils"
+)
+
+func TestFilterEMA(t *testing.T) {
+	assert := assert.New(t)
+
+	f := NewFilter(config.Filter{Type: models.FilterTypeEMA, Alpha: utils.Ptr(0.25)})
+
+	assert.Equal(40.0, f.Update(40))
+	assert.Equal(50.0, f.Update(80))
+	assert.Equal(47.5, f.Update(40))
+}
+
+func TestFilterMean(t *testing.T) {
+	assert := assert.New(t)
+
+	f := NewFilter(config.Filter{Type: models.FilterTypeMean, Window: utils.Ptr(3)})
+
+	assert.Equal(40.0, f.Update(40))
+	assert.Equal(50.0, f.Update(60))
+	assert.Equal(60.0, f.Update(80))
+	assert.Equal(80.0, f.Update(100))
+}
+
+func TestFilterMedian(t *testing.T) {
+	assert := assert.New(t)
+
+	f := NewFilter(config.Filter{Type: models.FilterTypeMedian, Window: utils.Ptr(3)})
+
+	assert.Equal(40.0, f.Update(40))
+	assert.Equal(50.0, f.Update(60))
+	assert.Equal(42.0, f.Update(42))
+	assert.Equal(60.0, f.Update(100))
+	assert.Equal(42.0, f.Update(41))
+}
+
+func TestServiceFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	s := New(config.Config{
+		Sensors: []config.Sensor{
+			{Name: "cpu", Filter: &config.Filter{Type: models.FilterTypeMean, Window: utils.Ptr(2)}},
+		},
+	})
+	sensor := NewMockSensorDriver(gomock.NewController(t))
+	s.sensorDrivers = map[string]SensorDriver{"cpu": sensor}
+
+	sensor.EXPECT().Value().Return(40.0, nil)
+	s.updateValues()
+	assert.Equal(40.0, s.values["cpu"])
+
+	sensor.EXPECT().Value().Return(80.0, nil)
+	s.updateValues()
+	assert.Equal(60.0, s.values["cpu"])
+}
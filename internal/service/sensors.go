@@ -0,0 +1,111 @@
+package service
+
+import (
+	"cmp"
+	"log/slog"
+
+	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/expr"
+	"github.com/IvanSafonov/fanctl/internal/models"
+)
+
+// Sensor error policy and health
+type sensorHealth struct {
+	onError   string
+	keepTicks int
+
+	failures int
+	hasValue bool
+}
+
+func newSensorHealth(conf config.Sensor) *sensorHealth {
+	keepTicks := 5
+	if conf.KeepTicks != nil {
+		keepTicks = *conf.KeepTicks
+	}
+
+	return &sensorHealth{
+		onError:   cmp.Or(conf.OnError, models.SensorOnErrorKeep),
+		keepTicks: keepTicks,
+	}
+}
+
+func createSensorsHealth(confs []config.Sensor) map[string]*sensorHealth {
+	health := make(map[string]*sensorHealth, len(confs))
+	for _, conf := range confs {
+		health[conf.Name] = newSensorHealth(conf)
+	}
+
+	return health
+}
+
+// Registers successful read. Logs recovery after failures.
+func (h *sensorHealth) success(name string) {
+	if h.failures != 0 {
+		slog.Info("sensor recovered", "sensor", name, "failures", h.failures)
+	}
+
+	h.failures = 0
+	h.hasValue = true
+}
+
+// Registers failed read. Returns what to do with the sensor value:
+// keep the last value, drop it or switch fans to failsafe level.
+// Keep turns into failsafe after keep ticks.
+func (h *sensorHealth) failure(name string, err error) string {
+	h.failures++
+
+	if h.failures == 1 {
+		slog.Error("failed to get sensor value", "sensor", name, "error", err)
+	} else {
+		slog.Debug("failed to get sensor value", "sensor", name, "error", err, "failures", h.failures)
+	}
+
+	if h.onError == models.SensorOnErrorKeep && (!h.hasValue || h.failures > h.keepTicks) {
+		return models.SensorOnErrorFailsafe
+	}
+
+	return h.onError
+}
+
+// Sensor with value calculated from other sensors
+type virtualSensor struct {
+	name  string
+	expr  expr.Expr
+	names []string
+}
+
+// Returns virtual sensors in dependency order.
+// Config validation guarantees valid expressions without cycles.
+func createVirtualSensors(confs []config.Sensor) []virtualSensor {
+	exprs := make(map[string]expr.Expr)
+	deps := make(map[string][]string)
+
+	for _, conf := range confs {
+		if conf.Type != models.SensorTypeVirtual {
+			continue
+		}
+
+		e, err := expr.Parse(conf.Expr)
+		if err != nil {
+			slog.Error("failed to parse virtual sensor", "sensor", conf.Name, "error", err)
+			continue
+		}
+
+		exprs[conf.Name] = e
+		deps[conf.Name] = expr.Names(e)
+	}
+
+	order, err := expr.Order(deps)
+	if err != nil {
+		slog.Error("failed to order virtual sensors", "error", err)
+		return nil
+	}
+
+	sensors := make([]virtualSensor, 0, len(order))
+	for _, name := range order {
+		sensors = append(sensors, virtualSensor{name: name, expr: exprs[name], names: deps[name]})
+	}
+
+	return sensors
+}
@@ -2,34 +2,54 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/IvanSafonov/fanctl/internal/config"
+	"github.com/IvanSafonov/fanctl/internal/drivers"
+	"github.com/IvanSafonov/fanctl/internal/models"
 )
 
+const stallCommandTimeout = time.Minute
+
 type Service struct {
 	period time.Duration
 
-	profileDriver ProfileDriver
-	sensorDrivers map[string]SensorDriver
-	fans          []Fan
+	profileDriver  ProfileDriver
+	sensorDrivers  map[string]SensorDriver
+	virtualSensors []virtualSensor
+	sensorsHealth  map[string]*sensorHealth
+	filters        map[string]Filter
+	fans           []Fan
+	stallCommand   []string
 
-	profile string
-	values  map[string]float64
+	profile         string
+	profileFailures int
+	values          map[string]float64
+	failsafe        map[string]struct{}
+	idle            map[string]struct{}
+	emergency       bool
 }
 
 func New(conf config.Config) *Service {
 	s := Service{
-		period:        time.Second,
-		profileDriver: createProfile(conf.Profile),
-		sensorDrivers: createSensors(conf.Sensors),
-		fans:          createFans(conf.Fans),
-		values:        make(map[string]float64, len(conf.Sensors)),
+		period:         time.Second,
+		profileDriver:  createProfile(conf.Profile),
+		sensorDrivers:  createSensors(conf.Sensors),
+		virtualSensors: createVirtualSensors(conf.Sensors),
+		sensorsHealth:  createSensorsHealth(conf.Sensors),
+		filters:        createFilters(conf.Sensors),
+		fans:           createFans(conf.Fans),
+		stallCommand:   conf.StallCommand,
+		values:         make(map[string]float64, len(conf.Sensors)),
+		failsafe:       make(map[string]struct{}),
+		idle:           make(map[string]struct{}),
 	}
 
 	if conf.Period != nil {
@@ -54,9 +74,14 @@ func (s *Service) Init() error {
 		}
 	}
 
-	for _, fan := range s.fans {
+	for i, fan := range s.fans {
 		err := fan.driver.Init()
 		if err != nil {
+			// Initialized fans may be switched to manual control already
+			for j := range s.fans[:i] {
+				s.fans[j].Restore()
+			}
+
 			return fmt.Errorf("fan (%s) init: %w", fan.Name, err)
 		}
 	}
@@ -77,25 +102,20 @@ func (s *Service) Run(ctx context.Context) error {
 		case <-suspendSignal:
 			s.Suspend(ctx)
 		case <-ticker.C:
-			if err := s.Update(ctx); err != nil {
-				return err
-			}
+			s.Update(ctx)
 		}
 	}
 }
 
 // Updates service state
 // - Collect all sensor values to currentValues
-// - Update current profile
-// - Update fan level
-func (s *Service) Update(ctx context.Context) error {
-	if err := s.updateValues(); err != nil {
-		return err
-	}
+// - Update current profile, keep the last one if it fails
+// - Update fan level, use failsafe level if fan sensors failed
+// - Switch fans to emergency level if some fan is stalled
+func (s *Service) Update(ctx context.Context) {
+	s.updateValues()
 
-	if err := s.updateProfile(); err != nil {
-		return err
-	}
+	s.updateProfile()
 
 	if slog.Default().Enabled(ctx, slog.LevelDebug) {
 		fields := make([]any, 0, 2*len(s.values)+2)
@@ -108,12 +128,66 @@ func (s *Service) Update(ctx context.Context) error {
 	}
 
 	for i := range s.fans {
-		if err := s.fans[i].UpdateLevel(s.values); err != nil {
-			return err
+		fan := &s.fans[i]
+		fan.SetFailsafe(s.hasFailsafeSensor(fan))
+		fan.SetIdle(s.isFanIdle(fan))
+
+		if err := fan.UpdateLevel(s.values); err != nil {
+			if fan.failures == 1 {
+				slog.Error("failed to update fan level", "fan", fan.Name, "error", err)
+			} else {
+				slog.Debug("failed to update fan level", "fan", fan.Name, "error", err, "failures", fan.failures)
+			}
 		}
 	}
 
-	return nil
+	s.updateEmergency()
+}
+
+// Switches not stalled fans to emergency level when some fan is stalled.
+// Runs stall command once per emergency.
+func (s *Service) updateEmergency() {
+	var stalled []string
+	for i := range s.fans {
+		if s.fans[i].Stalled() {
+			stalled = append(stalled, s.fans[i].Name)
+		}
+	}
+
+	emergency := len(stalled) != 0
+	for i := range s.fans {
+		s.fans[i].SetEmergency(emergency && !s.fans[i].Stalled())
+	}
+
+	if emergency == s.emergency {
+		return
+	}
+
+	s.emergency = emergency
+	if emergency {
+		slog.Error("emergency mode on, fans stalled", "fans", stalled)
+		s.runStallCommand(stalled)
+	} else {
+		slog.Info("emergency mode off")
+	}
+}
+
+// Runs stall command in background, {fans} is replaced by stalled fan names
+func (s *Service) runStallCommand(fans []string) {
+	if len(s.stallCommand) == 0 {
+		return
+	}
+
+	args := make([]string, 0, len(s.stallCommand))
+	for _, arg := range s.stallCommand {
+		args = append(args, strings.ReplaceAll(arg, "{fans}", strings.Join(fans, ",")))
+	}
+
+	go func() {
+		if _, err := drivers.RunCommand(args, stallCommandTimeout); err != nil {
+			slog.Error("stall command failed", "error", err)
+		}
+	}()
 }
 
 func (s *Service) SetDefaultLevel() {
@@ -135,27 +209,130 @@ func (s *Service) Suspend(ctx context.Context) {
 	}
 }
 
-func (s *Service) updateValues() error {
+// Reads and filters sensor values. Failed sensors keep the last value,
+// are dropped or switch fans to failsafe level according to the sensor policy.
+// Virtual sensors are evaluated after all others in dependency order
+// and are idle if they can't be evaluated because of idle sensors.
+func (s *Service) updateValues() {
 	for name, driver := range s.sensorDrivers {
 		value, err := driver.Value()
-		if err != nil {
-			return fmt.Errorf("get sensor (%s) value: %w", name, err)
+		s.updateValue(name, value, err)
+	}
+
+	for _, virtual := range s.virtualSensors {
+		value, err := virtual.expr.Eval(s.values)
+		if err != nil && s.hasIdleSensor(virtual.names) {
+			err = drivers.ErrSensorIdle
 		}
 
+		s.updateValue(virtual.name, value, err)
+	}
+}
+
+func (s *Service) updateValue(name string, value float64, err error) {
+	health, ok := s.sensorsHealth[name]
+	if !ok {
+		health = newSensorHealth(config.Sensor{})
+		s.sensorsHealth[name] = health
+	}
+
+	if errors.Is(err, drivers.ErrSensorUnchanged) {
+		return
+	}
+
+	if errors.Is(err, drivers.ErrSensorIdle) {
+		slog.Debug("sensor is idle", "sensor", name)
+		delete(s.values, name)
+		delete(s.failsafe, name)
+		s.idle[name] = struct{}{}
+		return
+	}
+
+	delete(s.idle, name)
+
+	if err == nil {
+		if filter, ok := s.filters[name]; ok {
+			value = filter.Update(value)
+		}
+
+		health.success(name)
 		s.values[name] = value
+		delete(s.failsafe, name)
+		return
 	}
 
-	return nil
+	switch health.failure(name, err) {
+	case models.SensorOnErrorDrop:
+		delete(s.values, name)
+	case models.SensorOnErrorFailsafe:
+		delete(s.values, name)
+		s.failsafe[name] = struct{}{}
+	}
 }
 
-func (s *Service) updateProfile() error {
+func (s *Service) hasIdleSensor(names []string) bool {
+	for _, name := range names {
+		if _, ok := s.idle[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns true if all fan sensors without values are idle
+func (s *Service) isFanIdle(fan *Fan) bool {
+	idle := false
+	for name := range s.sensorsHealth {
+		if !fan.UsesSensor(name) {
+			continue
+		}
+
+		if _, ok := s.values[name]; ok {
+			continue
+		}
+
+		if _, ok := s.idle[name]; !ok {
+			return false
+		}
+
+		idle = true
+	}
+
+	return idle
+}
+
+func (s *Service) hasFailsafeSensor(fan *Fan) bool {
+	for name := range s.failsafe {
+		if fan.UsesSensor(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Keeps the last profile if the driver fails and tries again on the next update
+func (s *Service) updateProfile() {
 	if s.profileDriver == nil {
-		return nil
+		return
 	}
 
 	profile, err := s.profileDriver.State()
 	if err != nil {
-		return fmt.Errorf("get profile: %w", err)
+		s.profileFailures++
+		if s.profileFailures == 1 {
+			slog.Error("failed to get profile", "error", err)
+		} else {
+			slog.Debug("failed to get profile", "error", err, "failures", s.profileFailures)
+		}
+
+		return
+	}
+
+	if s.profileFailures != 0 {
+		slog.Info("profile recovered", "failures", s.profileFailures)
+		s.profileFailures = 0
 	}
 
 	if s.profile != profile {
@@ -166,6 +343,4 @@ func (s *Service) updateProfile() error {
 			s.fans[i].UpdateProfile(profile)
 		}
 	}
-
-	return nil
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -73,6 +74,36 @@ func TestServiceInit(t *testing.T) {
 	assert.NoError(err)
 }
 
+func TestServiceInitFanErrorRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	fan0 := NewMockFanDriver(ctrl)
+	restorer0 := NewMockFanRestorer(ctrl)
+	fan1 := NewMockFanDriver(ctrl)
+	restorer1 := NewMockFanRestorer(ctrl)
+
+	s := Service{
+		fans: []Fan{
+			{Name: "cpu", driver: struct {
+				FanDriver
+				FanRestorer
+			}{fan0, restorer0}},
+			{Name: "gpu", driver: struct {
+				FanDriver
+				FanRestorer
+			}{fan1, restorer1}},
+		},
+	}
+
+	gomock.InOrder(
+		fan0.EXPECT().Init(),
+		fan1.EXPECT().Init().Return(errors.New("fan error")),
+		restorer0.EXPECT().Restore(),
+	)
+
+	assert.EqualError(t, s.Init(), "fan (gpu) init: fan error")
+}
+
 func TestServiceRunUnnamedWithProfiles(t *testing.T) {
 	assert := assert.New(t)
 	ctrl := gomock.NewController(t)
@@ -207,3 +238,277 @@ func TestServiceRunRepeat(t *testing.T) {
 	err := s.Run(ctx)
 	assert.NoError(err)
 }
+
+func TestServiceSetDefaultLevelRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	fan := NewMockFanDriver(ctrl)
+	fan.EXPECT().Defaults().Return(drivers.FanDefaults{Repeat: 10, Level: "255"})
+	restorer := NewMockFanRestorer(ctrl)
+
+	s := New(config.Config{})
+	s.fans = []Fan{NewFan(
+		struct {
+			FanDriver
+			FanRestorer
+		}{fan, restorer},
+		config.Fan{},
+	)}
+
+	gomock.InOrder(
+		fan.EXPECT().SetLevel("255"),
+		restorer.EXPECT().Restore(),
+	)
+
+	s.SetDefaultLevel()
+}
+
+func TestServiceEmergency(t *testing.T) {
+	assert := assert.New(t)
+
+	s := New(config.Config{})
+	s.fans = []Fan{{Name: "cpu", stalled: true}, {Name: "gpu"}}
+
+	s.updateEmergency()
+	assert.True(s.emergency)
+	assert.False(s.fans[0].emergency)
+	assert.True(s.fans[1].emergency)
+
+	s.fans[0].stalled = false
+	s.updateEmergency()
+	assert.False(s.emergency)
+	assert.False(s.fans[1].emergency)
+}
+
+func TestServiceSensorErrors(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	keep := NewMockSensorDriver(ctrl)
+	drop := NewMockSensorDriver(ctrl)
+	failsafe := NewMockSensorDriver(ctrl)
+
+	s := New(config.Config{
+		Sensors: []config.Sensor{
+			{Name: "keep", KeepTicks: utils.Ptr(1)},
+			{Name: "drop", OnError: models.SensorOnErrorDrop},
+			{Name: "failsafe", OnError: models.SensorOnErrorFailsafe},
+		},
+	})
+	s.sensorDrivers = map[string]SensorDriver{
+		"keep":     keep,
+		"drop":     drop,
+		"failsafe": failsafe,
+	}
+
+	errSensor := errors.New("sensor error")
+
+	keep.EXPECT().Value().Return(1.0, nil)
+	drop.EXPECT().Value().Return(2.0, nil)
+	failsafe.EXPECT().Value().Return(3.0, nil)
+	s.updateValues()
+	assert.Equal(map[string]float64{"keep": 1, "drop": 2, "failsafe": 3}, s.values)
+	assert.Empty(s.failsafe)
+
+	keep.EXPECT().Value().Return(0.0, errSensor)
+	drop.EXPECT().Value().Return(0.0, errSensor)
+	failsafe.EXPECT().Value().Return(0.0, errSensor)
+	s.updateValues()
+	assert.Equal(map[string]float64{"keep": 1}, s.values)
+	assert.Equal(map[string]struct{}{"failsafe": {}}, s.failsafe)
+
+	keep.EXPECT().Value().Return(0.0, errSensor)
+	drop.EXPECT().Value().Return(0.0, errSensor)
+	failsafe.EXPECT().Value().Return(0.0, errSensor)
+	s.updateValues()
+	assert.Empty(s.values)
+	assert.Equal(map[string]struct{}{"keep": {}, "failsafe": {}}, s.failsafe)
+
+	keep.EXPECT().Value().Return(4.0, nil)
+	drop.EXPECT().Value().Return(5.0, nil)
+	failsafe.EXPECT().Value().Return(6.0, nil)
+	s.updateValues()
+	assert.Equal(map[string]float64{"keep": 4, "drop": 5, "failsafe": 6}, s.values)
+	assert.Empty(s.failsafe)
+	assert.Zero(s.sensorsHealth["keep"].failures)
+}
+
+func TestServiceUpdateFailsafe(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	fan := NewMockFanDriver(ctrl)
+	fan.EXPECT().Defaults().Return(drivers.FanDefaults{Repeat: 1000, Level: "auto"})
+	sensor := NewMockSensorDriver(ctrl)
+
+	s := New(config.Config{})
+	s.sensorDrivers = map[string]SensorDriver{"cpu": sensor}
+	s.fans = []Fan{NewFan(fan, config.Fan{
+		Sensors:       []string{"cpu"},
+		FailsafeLevel: "7",
+		Levels: []config.Level{
+			{Level: "1", Max: utils.Ptr(50.0)},
+		},
+	})}
+
+	sensor.EXPECT().Value().Return(0.0, errors.New("sensor error"))
+	fan.EXPECT().SetLevel("7").Return(errors.New("fan error"))
+	s.Update(context.Background())
+	assert.Equal(1, s.fans[0].failures)
+
+	sensor.EXPECT().Value().Return(40.0, nil)
+	fan.EXPECT().SetLevel("1")
+	s.Update(context.Background())
+	assert.Zero(s.fans[0].failures)
+	assert.False(s.fans[0].failsafe)
+}
+
+func TestServiceVirtualSensors(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	cpu := NewMockSensorDriver(ctrl)
+	gpu := NewMockSensorDriver(ctrl)
+
+	s := New(config.Config{
+		Sensors: []config.Sensor{
+			{Name: "hot", Type: models.SensorTypeVirtual, Expr: "max(both, gpu - 10)"},
+			{Name: "both", Type: models.SensorTypeVirtual, Expr: "cpu + gpu"},
+			{Name: "cpu", OnError: models.SensorOnErrorDrop},
+		},
+	})
+	s.sensorDrivers = map[string]SensorDriver{"cpu": cpu, "gpu": gpu}
+
+	cpu.EXPECT().Value().Return(10.0, nil)
+	gpu.EXPECT().Value().Return(50.0, nil)
+	s.updateValues()
+	assert.Equal(map[string]float64{"cpu": 10, "gpu": 50, "both": 60, "hot": 60}, s.values)
+
+	cpu.EXPECT().Value().Return(0.0, errors.New("sensor error"))
+	gpu.EXPECT().Value().Return(90.0, nil)
+	s.updateValues()
+	assert.Equal(map[string]float64{"gpu": 90, "both": 60, "hot": 80}, s.values)
+	assert.Empty(s.failsafe)
+}
+
+func TestServiceSensorIdle(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	disk := NewMockSensorDriver(ctrl)
+	fan := NewMockFanDriver(ctrl)
+	fan.EXPECT().Defaults().Return(drivers.FanDefaults{Repeat: 1000, Level: "auto"})
+
+	s := New(config.Config{
+		Sensors: []config.Sensor{
+			{Name: "disk", OnError: models.SensorOnErrorFailsafe},
+		},
+	})
+	s.sensorDrivers = map[string]SensorDriver{"disk": disk}
+	s.fans = []Fan{NewFan(fan, config.Fan{
+		FailsafeLevel: "full",
+		Levels: []config.Level{
+			{Level: "0", Max: utils.Ptr(50.0)},
+			{Level: "1", Min: utils.Ptr(45.0)},
+		},
+	})}
+
+	disk.EXPECT().Value().Return(0.0, drivers.ErrSensorIdle)
+	fan.EXPECT().SetLevel("auto")
+	s.Update(context.Background())
+	assert.Empty(s.values)
+	assert.Equal("auto", s.fans[0].level)
+
+	disk.EXPECT().Value().Return(60.0, nil)
+	fan.EXPECT().SetLevel("1")
+	s.Update(context.Background())
+	assert.Equal(map[string]float64{"disk": 60}, s.values)
+	assert.Equal("1", s.fans[0].level)
+
+	disk.EXPECT().Value().Return(0.0, drivers.ErrSensorIdle)
+	s.Update(context.Background())
+	assert.Empty(s.values)
+	assert.Empty(s.failsafe)
+	assert.Zero(s.sensorsHealth["disk"].failures)
+	assert.Equal("1", s.fans[0].level)
+
+	disk.EXPECT().Value().Return(0.0, errors.New("sensor error"))
+	fan.EXPECT().SetLevel("full")
+	s.Update(context.Background())
+	assert.Equal("full", s.fans[0].level)
+}
+
+func TestServiceVirtualSensorIdle(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	disk := NewMockSensorDriver(ctrl)
+
+	s := New(config.Config{
+		Sensors: []config.Sensor{
+			{Name: "disk"},
+			{Name: "hot", Type: models.SensorTypeVirtual, Expr: "disk + 10", OnError: models.SensorOnErrorFailsafe},
+		},
+	})
+	s.sensorDrivers = map[string]SensorDriver{"disk": disk}
+
+	disk.EXPECT().Value().Return(0.0, drivers.ErrSensorIdle)
+	s.updateValues()
+	assert.Empty(s.values)
+	assert.Empty(s.failsafe)
+	assert.Equal(map[string]struct{}{"disk": {}, "hot": {}}, s.idle)
+}
+
+func TestServiceProfileError(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	profile := NewMockProfileDriver(ctrl)
+
+	s := New(config.Config{})
+	s.profileDriver = profile
+
+	profile.EXPECT().State().Return("low", nil)
+	s.Update(context.Background())
+	assert.Equal("low", s.profile)
+
+	profile.EXPECT().State().Return("", errors.New("profile error")).Times(2)
+	s.Update(context.Background())
+	s.Update(context.Background())
+	assert.Equal("low", s.profile)
+	assert.Equal(2, s.profileFailures)
+
+	profile.EXPECT().State().Return("high", nil)
+	s.Update(context.Background())
+	assert.Equal("high", s.profile)
+	assert.Zero(s.profileFailures)
+}
+
+func TestServiceCommandSensorError(t *testing.T) {
+	assert := assert.New(t)
+
+	s := New(config.Config{
+		Sensors: []config.Sensor{
+			{Name: "cmd", KeepTicks: utils.Ptr(2)},
+		},
+	})
+
+	sensor := drivers.NewSensorCommand(config.Sensor{
+		Command:  []string{"false"},
+		Interval: models.SecondsPtr(1000),
+	})
+	assert.Error(sensor.Init())
+
+	s.sensorDrivers = map[string]SensorDriver{"cmd": sensor}
+	s.values["cmd"] = 40
+	s.sensorsHealth["cmd"].hasValue = true
+
+	// One failed command run is one failure, not one per update
+	for range 5 {
+		s.updateValues()
+	}
+
+	assert.Equal(1, s.sensorsHealth["cmd"].failures)
+	assert.Equal(map[string]float64{"cmd": 40}, s.values)
+	assert.Empty(s.failsafe)
+}